@@ -0,0 +1,99 @@
+package main
+
+import (
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// Labels Crossplane sets on composite resources that belong to a claim.
+const (
+	LabelClaimName      = "crossplane.io/claim-name"
+	LabelClaimNamespace = "crossplane.io/claim-namespace"
+)
+
+// defaultAutoTagKeys are the tag keys of each field, without the prefix.
+var defaultAutoTagKeys = map[v1beta1.AutoTagField]string{
+	v1beta1.AutoTagName:                "xr",
+	v1beta1.AutoTagNamespace:           "namespace",
+	v1beta1.AutoTagKind:                "kind",
+	v1beta1.AutoTagAPIVersion:          "apiversion",
+	v1beta1.AutoTagUID:                 "uid",
+	v1beta1.AutoTagComposition:         "composition",
+	v1beta1.AutoTagCompositionRevision: "composition-revision",
+	v1beta1.AutoTagClaimName:           "claim-name",
+	v1beta1.AutoTagClaimNamespace:      "claim-namespace",
+}
+
+// ResolveAutoTags returns tags derived from the observed composite resource.
+// Fields without a value are skipped.
+func ResolveAutoTags(in *v1beta1.AutoTags, oxr *resource.Composite) TagUpdater {
+	tu := TagUpdater{}
+
+	if in == nil || oxr == nil || oxr.Resource == nil {
+		return tu
+	}
+
+	tags := v1beta1.Tags{}
+
+	for _, field := range in.GetFields() {
+		value := AutoTagValue(field, oxr)
+		if value == "" {
+			continue
+		}
+
+		key, ok := in.Keys[field]
+		if !ok || key == "" {
+			key = defaultAutoTagKeys[field]
+		}
+
+		tags[in.GetPrefix()+key] = value
+	}
+
+	if in.GetPolicy() == v1beta1.ExistingTagPolicyRetain {
+		tu.Retain = tags
+	} else {
+		tu.Replace = tags
+	}
+
+	return tu
+}
+
+// AutoTagValue returns the value of a field of the composite resource. The
+// Composition references are read from Crossplane v2 and v1 composites.
+func AutoTagValue(field v1beta1.AutoTagField, oxr *resource.Composite) string {
+	xr := oxr.Resource
+
+	switch field {
+	case v1beta1.AutoTagName:
+		return xr.GetName()
+	case v1beta1.AutoTagNamespace:
+		return xr.GetNamespace()
+	case v1beta1.AutoTagKind:
+		return xr.GetKind()
+	case v1beta1.AutoTagAPIVersion:
+		return xr.GetAPIVersion()
+	case v1beta1.AutoTagUID:
+		return string(xr.GetUID())
+	case v1beta1.AutoTagComposition:
+		return firstString(xr.GetString, "spec.crossplane.compositionRef.name", "spec.compositionRef.name")
+	case v1beta1.AutoTagCompositionRevision:
+		return firstString(xr.GetString, "spec.crossplane.compositionRevisionRef.name", "spec.compositionRevisionRef.name")
+	case v1beta1.AutoTagClaimName:
+		return xr.GetLabels()[LabelClaimName]
+	case v1beta1.AutoTagClaimNamespace:
+		return xr.GetLabels()[LabelClaimNamespace]
+	}
+
+	return ""
+}
+
+// firstString returns the first non-empty string found at the field paths.
+func firstString(get func(string) (string, error), paths ...string) string {
+	for _, p := range paths {
+		if v, err := get(p); err == nil && v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+)
+
+func TestResolveAutoTags(t *testing.T) {
+	claimed := &resource.Composite{
+		Resource: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "example.crossplane.io/v1",
+			"kind":       "XNetwork",
+			"metadata": map[string]any{
+				"name": "my-network-abcde",
+				"uid":  "8a7b6c5d",
+				"labels": map[string]any{
+					LabelClaimName:      "my-network",
+					LabelClaimNamespace: "team-a",
+				},
+			},
+			"spec": map[string]any{
+				"compositionRef":         map[string]any{"name": "network-aws"},
+				"compositionRevisionRef": map[string]any{"name": "network-aws-1a2b3c"},
+			},
+		}}},
+	}
+
+	namespaced := &resource.Composite{
+		Resource: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "example.crossplane.io/v1",
+			"kind":       "Network",
+			"metadata": map[string]any{
+				"name":      "my-network",
+				"namespace": "team-a",
+			},
+			"spec": map[string]any{
+				"crossplane": map[string]any{
+					"compositionRef": map[string]any{"name": "network-aws"},
+				},
+			},
+		}}},
+	}
+
+	type args struct {
+		in  *v1beta1.AutoTags
+		oxr *resource.Composite
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   TagUpdater
+	}{
+		"Disabled": {
+			reason: "No tags are returned without autoTags input",
+			args:   args{oxr: claimed},
+			want:   TagUpdater{},
+		},
+		"ClaimedComposite": {
+			reason: "All fields are added with the default prefix, skipping the namespace of a cluster scoped Composite",
+			args:   args{in: &v1beta1.AutoTags{}, oxr: claimed},
+			want: TagUpdater{Replace: v1beta1.Tags{
+				"crossplane-xr":                   "my-network-abcde",
+				"crossplane-kind":                 "XNetwork",
+				"crossplane-apiversion":           "example.crossplane.io/v1",
+				"crossplane-uid":                  "8a7b6c5d",
+				"crossplane-composition":          "network-aws",
+				"crossplane-composition-revision": "network-aws-1a2b3c",
+				"crossplane-claim-name":           "my-network",
+				"crossplane-claim-namespace":      "team-a",
+			}},
+		},
+		"NamespacedComposite": {
+			reason: "The Composition is read from the Crossplane v2 composite spec",
+			args: args{
+				in:  &v1beta1.AutoTags{Fields: []v1beta1.AutoTagField{v1beta1.AutoTagName, v1beta1.AutoTagNamespace, v1beta1.AutoTagComposition}},
+				oxr: namespaced,
+			},
+			want: TagUpdater{Replace: v1beta1.Tags{
+				"crossplane-xr":          "my-network",
+				"crossplane-namespace":   "team-a",
+				"crossplane-composition": "network-aws",
+			}},
+		},
+		"CustomKeys": {
+			reason: "Keys and the prefix can be changed, and Retain tags are returned as Retain",
+			args: args{
+				in: &v1beta1.AutoTags{
+					Fields: []v1beta1.AutoTagField{v1beta1.AutoTagName, v1beta1.AutoTagClaimName},
+					Prefix: ptr.To("xp:"),
+					Keys:   map[v1beta1.AutoTagField]string{v1beta1.AutoTagClaimName: "claim"},
+					Policy: v1beta1.ExistingTagPolicyRetain,
+				},
+				oxr: claimed,
+			},
+			want: TagUpdater{Retain: v1beta1.Tags{
+				"xp:xr":    "my-network-abcde",
+				"xp:claim": "my-network",
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ResolveAutoTags(tc.args.in, tc.args.oxr)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nResolveAutoTags(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
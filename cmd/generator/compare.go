@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/crossplane-contrib/function-tag-manager/cmd/generator/render"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+// ReportFormat is the format of a comparison report.
+type ReportFormat string
+
+// Formats of comparison reports.
+const (
+	ReportText     ReportFormat = "text"
+	ReportMarkdown ReportFormat = "markdown"
+	ReportJSON     ReportFormat = "json"
+)
+
+// KindChange is a kind that was added or removed.
+type KindChange struct {
+	GroupKind string `json:"groupKind"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// FilterDiff is the change of the filters of a provider between two scans.
+type FilterDiff struct {
+	// Provider is the name of the provider.
+	Provider string `json:"provider"`
+	// From and To describe the compared filters, like a file or a version.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Kinds and Taggable count the kinds, and the kinds that support tags,
+	// of the new filters.
+	Kinds    int `json:"kinds"`
+	Taggable int `json:"taggable"`
+	// Added and Removed are the kinds that are only in the new or the old
+	// filters.
+	Added   []KindChange `json:"added"`
+	Removed []KindChange `json:"removed"`
+	// Enabled are the kinds that support tags now, but did not before.
+	Enabled []string `json:"enabled"`
+	// Disabled are the kinds that supported tags before, but do not now.
+	Disabled []string `json:"disabled"`
+}
+
+// HasChanges returns true if any kind was added, removed or changed.
+func (d FilterDiff) HasChanges() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Enabled)+len(d.Disabled) > 0
+}
+
+// CompareFilters returns the changes between the old and new filters of a
+// provider. The kinds of each change are sorted. Entries of API versions are
+// compared by their group/version/Kind.
+func CompareFilters(provider string, old, updated render.FilterList) FilterDiff {
+	d := FilterDiff{
+		Provider: provider,
+		Added:    make([]KindChange, 0),
+		Removed:  make([]KindChange, 0),
+		Enabled:  make([]string, 0),
+		Disabled: make([]string, 0),
+	}
+
+	before := make(map[string]bool, len(old))
+	for _, f := range old {
+		before[f.Key()] = f.Enabled
+	}
+
+	after := make(map[string]bool, len(updated))
+
+	for _, f := range updated {
+		key := f.Key()
+		after[key] = f.Enabled
+
+		// Entries of API versions are counted with their kind
+		if f.APIVersion == "" {
+			d.Kinds++
+			if f.Enabled {
+				d.Taggable++
+			}
+		}
+
+		enabled, ok := before[key]
+
+		switch {
+		case !ok:
+			d.Added = append(d.Added, KindChange{GroupKind: key, Enabled: f.Enabled})
+		case !enabled && f.Enabled:
+			d.Enabled = append(d.Enabled, key)
+		case enabled && !f.Enabled:
+			d.Disabled = append(d.Disabled, key)
+		}
+	}
+
+	for _, f := range old {
+		if _, ok := after[f.Key()]; !ok {
+			d.Removed = append(d.Removed, KindChange{GroupKind: f.Key(), Enabled: f.Enabled})
+		}
+	}
+
+	byGroupKind := func(a, b KindChange) int { return strings.Compare(a.GroupKind, b.GroupKind) }
+	slices.SortFunc(d.Added, byGroupKind)
+	slices.SortFunc(d.Removed, byGroupKind)
+	slices.Sort(d.Enabled)
+	slices.Sort(d.Disabled)
+
+	return d
+}
+
+// LoadFilterFile returns the filters of a generated Go file, which are the
+// entries of the ResourceFilter literals of its functions.
+func LoadFilterFile(path string) (render.FilterList, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse filter file %q", path)
+	}
+
+	filter := render.FilterList{}
+
+	var perr error
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+
+		if t, ok := lit.Type.(*ast.Ident); !ok || t.Name != "ResourceFilter" {
+			return true
+		}
+
+		for _, e := range lit.Elts {
+			kv, ok := e.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+
+			k, kok := kv.Key.(*ast.BasicLit)
+			v, vok := kv.Value.(*ast.Ident)
+
+			if !kok || !vok || k.Kind != token.STRING {
+				continue
+			}
+
+			key, err := strconv.Unquote(k.Value)
+			if err != nil {
+				perr = errors.Wrapf(err, "cannot parse key %s of filter file %q", k.Value, path)
+				return false
+			}
+
+			f := render.ParseKey(key)
+			f.Enabled = v.Name == "true"
+			filter = append(filter, f)
+		}
+
+		return false
+	})
+
+	return filter, perr
+}
+
+// Compare scans providers and compares their filters with a baseline, and
+// writes a report of the changes. The baseline is a generated filter file,
+// a directory of generated filter files named like the output of each
+// provider, or a Git ref of the provider repositories.
+func Compare(log logging.Logger, providers []ProviderConfig, compareWith string, format ReportFormat, w io.Writer) error {
+	diffs := make([]FilterDiff, 0, len(providers))
+
+	for _, p := range providers {
+		log.Info("Comparing resource filters", "provider", p.Name, "with", compareWith)
+
+		updated, err := ScanProvider(log, p)
+		if err != nil {
+			return errors.Wrapf(err, "cannot scan provider %q", p.Name)
+		}
+
+		old, from, err := loadBaseline(log, p, compareWith, len(providers))
+		if err != nil {
+			return errors.Wrapf(err, "cannot load filters of provider %q to compare with", p.Name)
+		}
+
+		d := CompareFilters(p.Name, old, updated.Filters)
+		d.From = from
+		d.To = revisionLabel(updated)
+
+		diffs = append(diffs, d)
+	}
+
+	return WriteReport(w, format, diffs)
+}
+
+// loadBaseline returns the filters of a provider to compare with, and a
+// description of where they came from.
+func loadBaseline(log logging.Logger, p ProviderConfig, compareWith string, providers int) (render.FilterList, string, error) {
+	info, err := os.Stat(compareWith)
+
+	switch {
+	case err == nil && info.IsDir():
+		path := filepath.Join(compareWith, filepath.Base(p.Output))
+		filter, err := LoadFilterFile(path)
+
+		return filter, path, err
+	case err == nil && providers > 1:
+		return nil, "", errors.Errorf("cannot compare %d providers with the single file %q, use a directory", providers, compareWith)
+	case err == nil:
+		filter, err := LoadFilterFile(compareWith)
+		return filter, compareWith, err
+	case p.GetSourceType() != SourceGit:
+		return nil, "", errors.Errorf("%q is not a file, and comparing with a ref requires a git source", compareWith)
+	}
+
+	old := p
+	old.Ref = compareWith
+	old.Versions = nil
+
+	provider, err := ScanProvider(log, old)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return provider.Filters, revisionLabel(provider), nil
+}
+
+// revisionLabel describes the revision of scanned filters.
+func revisionLabel(p render.Provider) string {
+	for _, l := range []string{p.Version, p.Commit, p.Ref, p.Source} {
+		if l != "" {
+			return l
+		}
+	}
+
+	return p.Name
+}
+
+// WriteReport writes the changes of the filters of providers.
+func WriteReport(w io.Writer, format ReportFormat, diffs []FilterDiff) error {
+	switch format {
+	case ReportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return errors.Wrap(enc.Encode(diffs), "cannot write report")
+	case ReportMarkdown:
+		return writeMarkdownReport(w, diffs)
+	case ReportText, "":
+		return writeTextReport(w, diffs)
+	}
+
+	return errors.Errorf("unknown report format %q", format)
+}
+
+func writeTextReport(w io.Writer, diffs []FilterDiff) error {
+	ew := &errWriter{w: w}
+
+	for _, d := range diffs {
+		ew.printf("%s: %s -> %s\n", d.Provider, d.From, d.To)
+		ew.printf("  %d kinds, %d support tags\n", d.Kinds, d.Taggable)
+
+		if !d.HasChanges() {
+			ew.printf("  no changes\n")
+			continue
+		}
+
+		if len(d.Added) > 0 {
+			ew.printf("  added (%d):\n", len(d.Added))
+
+			for _, c := range d.Added {
+				ew.printf("    + %s (%t)\n", c.GroupKind, c.Enabled)
+			}
+		}
+
+		if len(d.Removed) > 0 {
+			ew.printf("  removed (%d):\n", len(d.Removed))
+
+			for _, c := range d.Removed {
+				ew.printf("    - %s (%t)\n", c.GroupKind, c.Enabled)
+			}
+		}
+
+		if len(d.Enabled) > 0 {
+			ew.printf("  false -> true (%d):\n", len(d.Enabled))
+
+			for _, k := range d.Enabled {
+				ew.printf("    ~ %s\n", k)
+			}
+		}
+
+		if len(d.Disabled) > 0 {
+			ew.printf("  true -> false (%d):\n", len(d.Disabled))
+
+			for _, k := range d.Disabled {
+				ew.printf("    ~ %s\n", k)
+			}
+		}
+	}
+
+	return errors.Wrap(ew.err, "cannot write report")
+}
+
+func writeMarkdownReport(w io.Writer, diffs []FilterDiff) error {
+	ew := &errWriter{w: w}
+
+	for i, d := range diffs {
+		if i > 0 {
+			ew.printf("\n")
+		}
+
+		ew.printf("## %s\n\n", d.Provider)
+		ew.printf("Compared `%s` with `%s`: %d kinds, %d support tags.\n", d.From, d.To, d.Kinds, d.Taggable)
+
+		if !d.HasChanges() {
+			ew.printf("\nNo changes.\n")
+			continue
+		}
+
+		if len(d.Added) > 0 {
+			ew.printf("\n### Added\n\n| Kind | Supports tags |\n|------|---------------|\n")
+
+			for _, c := range d.Added {
+				ew.printf("| `%s` | %t |\n", c.GroupKind, c.Enabled)
+			}
+		}
+
+		if len(d.Removed) > 0 {
+			ew.printf("\n### Removed\n\n| Kind | Supported tags |\n|------|----------------|\n")
+
+			for _, c := range d.Removed {
+				ew.printf("| `%s` | %t |\n", c.GroupKind, c.Enabled)
+			}
+		}
+
+		if len(d.Enabled) > 0 {
+			ew.printf("\n### Now Support Tags (false → true)\n\n")
+
+			for _, k := range d.Enabled {
+				ew.printf("- `%s`\n", k)
+			}
+		}
+
+		if len(d.Disabled) > 0 {
+			ew.printf("\n### No Longer Support Tags (true → false)\n\n")
+
+			for _, k := range d.Disabled {
+				ew.printf("- `%s`\n", k)
+			}
+		}
+	}
+
+	return errors.Wrap(ew.err, "cannot write report")
+}
+
+// errWriter keeps the first error of a series of writes.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err != nil {
+		return
+	}
+
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
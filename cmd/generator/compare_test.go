@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/cmd/generator/render"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+func TestCompareFilters(t *testing.T) {
+	type args struct {
+		old     render.FilterList
+		updated render.FilterList
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   FilterDiff
+	}{
+		"NoChanges": {
+			reason: "Identical filters have no changes",
+			args: args{
+				old:     render.FilterList{{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true}},
+				updated: render.FilterList{{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true}},
+			},
+			want: FilterDiff{
+				Provider: "provider-upjet-aws",
+				Kinds:    1,
+				Taggable: 1,
+				Added:    []KindChange{},
+				Removed:  []KindChange{},
+				Enabled:  []string{},
+				Disabled: []string{},
+			},
+		},
+		"AllChanges": {
+			reason: "Added, removed, enabled and disabled kinds and API versions are reported in order",
+			args: args{
+				old: render.FilterList{
+					{GroupKind: "ec2.aws.upbound.io/VPCIpamPoolCidrAllocation", Enabled: false},
+					{GroupKind: "ec2.aws.upbound.io/Legacy", Enabled: true},
+					{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+					{GroupKind: "s3.aws.upbound.io/BucketPolicy", Enabled: true},
+				},
+				updated: render.FilterList{
+					{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+					{GroupKind: "s3.aws.upbound.io/BucketPolicy", Enabled: false},
+					{GroupKind: "ec2.aws.upbound.io/VPCIpamPoolCidrAllocation", Enabled: true},
+					{GroupKind: "cloudfront.aws.upbound.io/Function", Enabled: true},
+					{GroupKind: "cloudfront.aws.upbound.io/CachePolicy", Enabled: false},
+				},
+			},
+			want: FilterDiff{
+				Provider: "provider-upjet-aws",
+				Kinds:    5,
+				Taggable: 3,
+				Added: []KindChange{
+					{GroupKind: "cloudfront.aws.upbound.io/CachePolicy", Enabled: false},
+					{GroupKind: "cloudfront.aws.upbound.io/Function", Enabled: true},
+				},
+				Removed:  []KindChange{{GroupKind: "ec2.aws.upbound.io/Legacy", Enabled: true}},
+				Enabled:  []string{"ec2.aws.upbound.io/VPCIpamPoolCidrAllocation"},
+				Disabled: []string{"s3.aws.upbound.io/BucketPolicy"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := CompareFilters("provider-upjet-aws", tc.args.old, tc.args.updated)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nCompareFilters(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestLoadFilterFile(t *testing.T) {
+	file := `package filters
+
+// NewAWSResourceFilter returns a map of resources that support tags.
+func NewAWSResourceFilter() ResourceFilter {
+	return ResourceFilter{
+		"s3.aws.upbound.io/Bucket":         true,
+		"s3.aws.upbound.io/v1beta1/Bucket": false,
+		"s3.aws.upbound.io/BucketPolicy":   false,
+	}
+}
+
+// NewAWSTagPaths returns the field paths of resources that do not keep tags in a map.
+func NewAWSTagPaths() TagPaths {
+	return TagPaths{
+		"autoscaling.aws.upbound.io/AutoscalingGroup": {
+			{Desired: "spec.forProvider.tag", Observed: "status.atProvider.tag", Shape: TagShapeKeyValueList},
+		},
+	}
+}
+`
+
+	path := filepath.Join(t.TempDir(), "zz_provider-upjet-aws.go")
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadFilterFile(path)
+	if err != nil {
+		t.Fatalf("LoadFilterFile(...): unexpected error: %v", err)
+	}
+
+	want := render.FilterList{
+		{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+		{GroupKind: "s3.aws.upbound.io/Bucket", APIVersion: "v1beta1", Enabled: false},
+		{GroupKind: "s3.aws.upbound.io/BucketPolicy", Enabled: false},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LoadFilterFile(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	diffs := []FilterDiff{
+		{
+			Provider: "provider-upjet-aws",
+			From:     "v2.6.0",
+			To:       "v2.7.0",
+			Kinds:    3,
+			Taggable: 2,
+			Added:    []KindChange{{GroupKind: "cloudfront.aws.upbound.io/Function", Enabled: true}},
+			Removed:  []KindChange{},
+			Enabled:  []string{"ec2.aws.upbound.io/VPCIpamPoolCidrAllocation"},
+			Disabled: []string{},
+		},
+		{
+			Provider: "provider-upjet-azure",
+			From:     "v2.6.0",
+			To:       "v2.7.0",
+			Kinds:    1,
+			Added:    []KindChange{},
+			Removed:  []KindChange{},
+			Enabled:  []string{},
+			Disabled: []string{},
+		},
+	}
+
+	cases := map[string]struct {
+		reason string
+		format ReportFormat
+		want   string
+	}{
+		"Text": {
+			reason: "The text report lists the changes of each provider",
+			format: ReportText,
+			want: `provider-upjet-aws: v2.6.0 -> v2.7.0
+  3 kinds, 2 support tags
+  added (1):
+    + cloudfront.aws.upbound.io/Function (true)
+  false -> true (1):
+    ~ ec2.aws.upbound.io/VPCIpamPoolCidrAllocation
+provider-upjet-azure: v2.6.0 -> v2.7.0
+  1 kinds, 0 support tags
+  no changes
+`,
+		},
+		"Markdown": {
+			reason: "The Markdown report has a section per provider",
+			format: ReportMarkdown,
+			want: "## provider-upjet-aws\n\n" +
+				"Compared `v2.6.0` with `v2.7.0`: 3 kinds, 2 support tags.\n\n" +
+				"### Added\n\n| Kind | Supports tags |\n|------|---------------|\n" +
+				"| `cloudfront.aws.upbound.io/Function` | true |\n\n" +
+				"### Now Support Tags (false → true)\n\n" +
+				"- `ec2.aws.upbound.io/VPCIpamPoolCidrAllocation`\n\n" +
+				"## provider-upjet-azure\n\n" +
+				"Compared `v2.6.0` with `v2.7.0`: 1 kinds, 0 support tags.\n\n" +
+				"No changes.\n",
+		},
+		"JSON": {
+			reason: "The JSON report has empty lists instead of nulls",
+			format: ReportJSON,
+			want: `[
+  {
+    "provider": "provider-upjet-aws",
+    "from": "v2.6.0",
+    "to": "v2.7.0",
+    "kinds": 3,
+    "taggable": 2,
+    "added": [
+      {
+        "groupKind": "cloudfront.aws.upbound.io/Function",
+        "enabled": true
+      }
+    ],
+    "removed": [],
+    "enabled": [
+      "ec2.aws.upbound.io/VPCIpamPoolCidrAllocation"
+    ],
+    "disabled": []
+  },
+  {
+    "provider": "provider-upjet-azure",
+    "from": "v2.6.0",
+    "to": "v2.7.0",
+    "kinds": 1,
+    "taggable": 0,
+    "added": [],
+    "removed": [],
+    "enabled": [],
+    "disabled": []
+  }
+]
+`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := WriteReport(buf, tc.format, diffs); err != nil {
+				t.Fatalf("%s\nWriteReport(...): unexpected error: %v", tc.reason, err)
+			}
+
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Errorf("%s\nWriteReport(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	const bucketFile = "package/crds/s3.aws.upbound.io_buckets.yaml"
+
+	upstream := newTestRepository(t)
+	v1 := upstream.Commit(map[string]string{bucketFile: strings.Replace(sourceBucketCRD, "tags:", "region:", 1)})
+	upstream.Tag("v1.0.0", v1)
+
+	v2 := upstream.Commit(map[string]string{bucketFile: sourceBucketCRD, "package/crds/acmpca.aws.upbound.io_certificates.yaml": sourceCertificateCRD})
+	upstream.Tag("v2.0.0", v2)
+
+	p := ProviderConfig{Name: "provider-upjet-aws", Source: upstream.Path, Ref: "v2.0.0"}
+	p.SetDefaults()
+	p.ResolvePaths(t.TempDir())
+
+	buf := &bytes.Buffer{}
+	if err := Compare(logging.NewNopLogger(), []ProviderConfig{p}, "v1.0.0", ReportText, buf); err != nil {
+		t.Fatalf("Compare(...): unexpected error: %v", err)
+	}
+
+	want := `provider-upjet-aws: v1.0.0 -> v2.0.0
+  2 kinds, 1 support tags
+  added (1):
+    + acmpca.aws.upbound.io/Certificate (false)
+  false -> true (1):
+    ~ s3.aws.upbound.io/Bucket
+`
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("Compare(...): -want, +got:\n%s", diff)
+	}
+}
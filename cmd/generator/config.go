@@ -0,0 +1,242 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/crossplane-contrib/function-tag-manager/cmd/generator/render"
+	"github.com/crossplane-contrib/function-tag-manager/filters/crd"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+)
+
+// Defaults of a ProviderConfig.
+const (
+	defaultRef          = "refs/remotes/origin/main"
+	defaultCRDDir       = "package/crds"
+	defaultTagFieldPath = "spec.forProvider.tags"
+)
+
+// Config declares the providers to generate filters for.
+type Config struct {
+	// Providers to generate filters for.
+	Providers []ProviderConfig `json:"providers"`
+	// Aggregate is the file that combines the filters of all providers.
+	Aggregate AggregateConfig `json:"aggregate"`
+	// Outputs are files of the filters of all providers in other formats.
+	Outputs []OutputConfig `json:"outputs,omitempty"`
+}
+
+// ProviderConfig declares how to generate the filters of a provider.
+type ProviderConfig struct {
+	// Name of the provider, like provider-upjet-aws.
+	Name string `json:"name"`
+	// Prefix of the generated function names, like AWS.
+	Prefix string `json:"prefix"`
+	// SourceType is where the CRDs are read from: git (default), dir, tar
+	// or xpkg.
+	SourceType SourceType `json:"sourceType,omitempty"`
+	// Source is the URL of the provider's Git repository, or the path of a
+	// directory, tarball or .xpkg file.
+	Source string `json:"source"`
+	// Ref is the Git tag, branch, commit or full reference to scan, like
+	// v1.0.0 or refs/remotes/origin/main. Defaults to refs/remotes/origin/main.
+	Ref string `json:"ref,omitempty"`
+	// RepositoryDir caches the cloned repository. Defaults to
+	// _work/providers/<name>.
+	RepositoryDir string `json:"repositoryDir,omitempty"`
+	// CRDDir is the directory of the CRDs in the repository, directory or
+	// tarball. Defaults to package/crds for git sources, and to the root of
+	// other sources. It is ignored for xpkg sources.
+	CRDDir string `json:"crdDir,omitempty"`
+	// GroupSuffix of the API groups of the provider family, like
+	// aws.upbound.io. Kinds of the family that are missing from its filters
+	// keep tags at its first tag field path.
+	GroupSuffix string `json:"groupSuffix,omitempty"`
+	// TagFieldPaths are the field paths of spec.forProvider to probe for
+	// tags, in order, like spec.forProvider.labels. Defaults to
+	// spec.forProvider.tags.
+	TagFieldPaths []string `json:"tagFieldPaths,omitempty"`
+	// Versions are release tags to scan, from the oldest to the latest, to
+	// record the versions in which kinds support tags.
+	Versions []string `json:"versions,omitempty"`
+	// ContinueOnError skips CRD files that cannot be examined, and kinds
+	// defined by CRDs with conflicting verdicts, and logs a summary of them
+	// instead of failing.
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+	// Output is the generated Go file. Defaults to zz_<name>.go.
+	Output string `json:"output,omitempty"`
+	// Template renders the filters of the provider.
+	Template string `json:"template"`
+}
+
+// AggregateConfig declares the file that combines the filters of all
+// providers, like NewResourceFilter.
+type AggregateConfig struct {
+	// Output is the generated Go file.
+	Output string `json:"output"`
+	// Template renders the combined filters.
+	Template string `json:"template"`
+}
+
+// OutputConfig declares a file of the filters of all providers in a
+// built-in format.
+type OutputConfig struct {
+	// Format of the file: json, yaml or markdown.
+	Format string `json:"format"`
+	// Output is the file.
+	Output string `json:"output"`
+}
+
+// LoadConfig reads a Config from a YAML file. Defaults are set, and
+// relative paths are resolved against the directory of the file.
+func LoadConfig(path string) (*Config, error) {
+	bs, err := os.ReadFile(path) //nolint:gosec // The config file is chosen by the user running the generator.
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read config file %q", path)
+	}
+
+	c := &Config{}
+	if err := yaml.UnmarshalStrict(bs, c); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse config file %q", path)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range c.Providers {
+		c.Providers[i].SetDefaults()
+		c.Providers[i].ResolvePaths(dir)
+	}
+
+	c.Aggregate.Output = resolvePath(dir, c.Aggregate.Output)
+	c.Aggregate.Template = resolvePath(dir, c.Aggregate.Template)
+
+	for i := range c.Outputs {
+		c.Outputs[i].Output = resolvePath(dir, c.Outputs[i].Output)
+	}
+
+	return c, c.Validate()
+}
+
+// Validate returns an error if the Config is incomplete.
+func (c *Config) Validate() error {
+	if len(c.Providers) == 0 {
+		return errors.New("config has no providers")
+	}
+
+	names := make(map[string]bool, len(c.Providers))
+	prefixes := make(map[string]bool, len(c.Providers))
+
+	for _, p := range c.Providers {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+
+		if names[p.Name] || prefixes[p.Prefix] {
+			return errors.Errorf("provider %q: name and prefix must be unique", p.Name)
+		}
+
+		names[p.Name] = true
+		prefixes[p.Prefix] = true
+	}
+
+	if c.Aggregate.Output == "" || c.Aggregate.Template == "" {
+		return errors.New("aggregate: output and template are required")
+	}
+
+	for _, o := range c.Outputs {
+		if !slices.Contains([]string{render.FormatJSON, render.FormatYAML, render.FormatMarkdown}, o.Format) {
+			return errors.Errorf("output %q: format must be json, yaml or markdown, got %q", o.Output, o.Format)
+		}
+
+		if o.Output == "" {
+			return errors.Errorf("output of format %q is required", o.Format)
+		}
+	}
+
+	return nil
+}
+
+// SetDefaults sets the defaults of unset fields.
+func (p *ProviderConfig) SetDefaults() {
+	if p.Ref == "" {
+		p.Ref = defaultRef
+	}
+
+	if p.RepositoryDir == "" {
+		p.RepositoryDir = filepath.Join("_work", "providers", p.Name)
+	}
+
+	if p.CRDDir == "" {
+		p.CRDDir = defaultCRDDir
+		if p.GetSourceType() != SourceGit {
+			p.CRDDir = "."
+		}
+	}
+
+	if len(p.TagFieldPaths) == 0 {
+		p.TagFieldPaths = []string{defaultTagFieldPath}
+	}
+
+	if p.Output == "" {
+		p.Output = "zz_" + p.Name + ".go"
+	}
+}
+
+// ResolvePaths resolves the relative local paths of the provider against a
+// directory. CRDDir is relative to the repository.
+func (p *ProviderConfig) ResolvePaths(dir string) {
+	p.RepositoryDir = resolvePath(dir, p.RepositoryDir)
+	if p.GetSourceType() != SourceGit {
+		p.Source = resolvePath(dir, p.Source)
+	}
+
+	p.Output = resolvePath(dir, p.Output)
+	p.Template = resolvePath(dir, p.Template)
+}
+
+// Validate returns an error if the provider is incomplete.
+func (p *ProviderConfig) Validate() error {
+	switch {
+	case p.Name == "":
+		return errors.New("provider name is required")
+	case !token.IsIdentifier(p.Prefix):
+		return errors.Errorf("provider %q: prefix %q is not a Go identifier", p.Name, p.Prefix)
+	case p.Source == "":
+		return errors.Errorf("provider %q: source is required", p.Name)
+	case !slices.Contains([]SourceType{SourceGit, SourceDir, SourceTar, SourceXpkg}, p.GetSourceType()):
+		return errors.Errorf("provider %q: unknown source type %q", p.Name, p.SourceType)
+	case len(p.Versions) > 0 && p.GetSourceType() != SourceGit:
+		return errors.Errorf("provider %q: versions require a git source", p.Name)
+	case p.Template == "":
+		return errors.Errorf("provider %q: template is required", p.Name)
+	}
+
+	for _, fp := range p.TagFieldPaths {
+		if _, err := crd.ParseTagFieldPath(fp); err != nil {
+			return errors.Wrapf(err, "provider %q", p.Name)
+		}
+	}
+
+	return nil
+}
+
+// GetSourceType returns where the CRDs are read from.
+func (p *ProviderConfig) GetSourceType() SourceType {
+	if p.SourceType == "" {
+		return SourceGit
+	}
+
+	return p.SourceType
+}
+
+// resolvePath returns a relative path joined to a directory.
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
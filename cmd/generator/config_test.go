@@ -0,0 +1,366 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+func TestLoadConfig(t *testing.T) {
+	type want struct {
+		cfg    *Config
+		errStr string
+	}
+
+	cases := map[string]struct {
+		reason string
+		config string
+		want   want
+	}{
+		"Defaults": {
+			reason: "Unset fields are defaulted and relative paths are resolved against the config directory",
+			config: `
+aggregate:
+  output: zz_filters.go
+  template: ../templates/filters.tmpl
+providers:
+- name: provider-upjet-aws
+  prefix: AWS
+  source: https://github.com/crossplane-contrib/provider-upjet-aws.git
+  template: ../templates/provider.tmpl
+`,
+			want: want{cfg: &Config{
+				Aggregate: AggregateConfig{
+					Output:   "config/zz_filters.go",
+					Template: "templates/filters.tmpl",
+				},
+				Providers: []ProviderConfig{{
+					Name:          "provider-upjet-aws",
+					Prefix:        "AWS",
+					Source:        "https://github.com/crossplane-contrib/provider-upjet-aws.git",
+					Ref:           defaultRef,
+					RepositoryDir: "config/_work/providers/provider-upjet-aws",
+					CRDDir:        defaultCRDDir,
+					TagFieldPaths: []string{defaultTagFieldPath},
+					Output:        "config/zz_provider-upjet-aws.go",
+					Template:      "templates/provider.tmpl",
+				}},
+			}},
+		},
+		"LocalSource": {
+			reason: "Local sources are resolved against the config directory and contain the CRDs at their root",
+			config: `
+aggregate: {output: zz_filters.go, template: filters.tmpl}
+providers:
+- {name: provider-upjet-aws, prefix: AWS, sourceType: xpkg, source: provider-upjet-aws.xpkg, template: provider.tmpl}
+`,
+			want: want{cfg: &Config{
+				Aggregate: AggregateConfig{
+					Output:   "config/zz_filters.go",
+					Template: "config/filters.tmpl",
+				},
+				Providers: []ProviderConfig{{
+					Name:          "provider-upjet-aws",
+					Prefix:        "AWS",
+					SourceType:    SourceXpkg,
+					Source:        "config/provider-upjet-aws.xpkg",
+					Ref:           defaultRef,
+					RepositoryDir: "config/_work/providers/provider-upjet-aws",
+					CRDDir:        ".",
+					TagFieldPaths: []string{defaultTagFieldPath},
+					Output:        "config/zz_provider-upjet-aws.go",
+					Template:      "config/provider.tmpl",
+				}},
+			}},
+		},
+		"UnknownSourceType": {
+			reason: "Only git, dir, tar and xpkg sources are supported",
+			config: `
+aggregate: {output: zz_filters.go, template: filters.tmpl}
+providers:
+- {name: provider-upjet-aws, prefix: AWS, sourceType: oci, source: aws, template: provider.tmpl}
+`,
+			want: want{errStr: `provider "provider-upjet-aws": unknown source type "oci"`},
+		},
+		"VersionsOfLocalSource": {
+			reason: "Versions are checked out of a Git repository",
+			config: `
+aggregate: {output: zz_filters.go, template: filters.tmpl}
+providers:
+- {name: provider-upjet-aws, prefix: AWS, sourceType: dir, source: crds, template: provider.tmpl, versions: [v1.0.0]}
+`,
+			want: want{errStr: `provider "provider-upjet-aws": versions require a git source`},
+		},
+		"Outputs": {
+			reason: "Outputs are resolved against the config directory",
+			config: `
+aggregate: {output: zz_filters.go, template: filters.tmpl}
+outputs:
+- {format: markdown, output: coverage.md}
+providers:
+- {name: provider-upjet-aws, prefix: AWS, source: aws.git, template: provider.tmpl}
+`,
+			want: want{cfg: &Config{
+				Aggregate: AggregateConfig{
+					Output:   "config/zz_filters.go",
+					Template: "config/filters.tmpl",
+				},
+				Outputs: []OutputConfig{{Format: "markdown", Output: "config/coverage.md"}},
+				Providers: []ProviderConfig{{
+					Name:          "provider-upjet-aws",
+					Prefix:        "AWS",
+					Source:        "aws.git",
+					Ref:           defaultRef,
+					RepositoryDir: "config/_work/providers/provider-upjet-aws",
+					CRDDir:        defaultCRDDir,
+					TagFieldPaths: []string{defaultTagFieldPath},
+					Output:        "config/zz_provider-upjet-aws.go",
+					Template:      "config/provider.tmpl",
+				}},
+			}},
+		},
+		"UnknownOutputFormat": {
+			reason: "Go source is only rendered from templates",
+			config: `
+aggregate: {output: zz_filters.go, template: filters.tmpl}
+outputs:
+- {format: go, output: filters.go}
+providers:
+- {name: provider-upjet-aws, prefix: AWS, source: aws.git, template: provider.tmpl}
+`,
+			want: want{errStr: `output "config/filters.go": format must be json, yaml or markdown, got "go"`},
+		},
+		"InvalidTagFieldPath": {
+			reason: "Tag field paths must be fields of spec.forProvider",
+			config: `
+aggregate: {output: zz_filters.go, template: filters.tmpl}
+providers:
+- {name: provider-upjet-gcp, prefix: GCP, source: gcp.git, template: provider.tmpl, tagFieldPaths: [spec.forProvider.labels, spec.initProvider.labels]}
+`,
+			want: want{errStr: `provider "provider-upjet-gcp": tag field path "spec.initProvider.labels" is not a field of spec.forProvider`},
+		},
+		"MalformedTagFieldPath": {
+			reason: "Tag field paths must parse",
+			config: `
+aggregate: {output: zz_filters.go, template: filters.tmpl}
+providers:
+- {name: provider-upjet-gcp, prefix: GCP, source: gcp.git, template: provider.tmpl, tagFieldPaths: ["spec.forProvider.labels["]}
+`,
+			want: want{errStr: `provider "provider-upjet-gcp": invalid tag field path "spec.forProvider.labels[": unterminated '[' at position 23`},
+		},
+		"InvalidPrefix": {
+			reason: "The prefix is used in function names",
+			config: `
+aggregate: {output: zz_filters.go, template: filters.tmpl}
+providers:
+- {name: provider-upjet-aws, prefix: aws-provider, source: aws.git, template: provider.tmpl}
+`,
+			want: want{errStr: `provider "provider-upjet-aws": prefix "aws-provider" is not a Go identifier`},
+		},
+		"DuplicatePrefix": {
+			reason: "Two providers cannot generate the same functions",
+			config: `
+aggregate: {output: zz_filters.go, template: filters.tmpl}
+providers:
+- {name: provider-upjet-aws, prefix: AWS, source: aws.git, template: provider.tmpl}
+- {name: provider-aws-legacy, prefix: AWS, source: aws.git, template: provider.tmpl}
+`,
+			want: want{errStr: `provider "provider-aws-legacy": name and prefix must be unique`},
+		},
+		"UnknownField": {
+			reason: "Misspelled fields are an error",
+			config: `
+aggregate: {output: zz_filters.go, template: filters.tmpl}
+providers:
+- {name: provider-upjet-aws, prefix: AWS, source: aws.git, template: provider.tmpl, crdDirectory: crds}
+`,
+			want: want{errStr: "failed to parse config file"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			t.Chdir(root)
+
+			if err := os.Mkdir("config", 0o750); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(filepath.Join("config", "providers.yaml"), []byte(tc.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConfig(filepath.Join("config", "providers.yaml"))
+
+			if tc.want.errStr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.want.errStr) {
+					t.Errorf("%s\nLoadConfig(...): want error containing %q, got %v", tc.reason, tc.want.errStr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("%s\nLoadConfig(...): unexpected error: %v", tc.reason, err)
+			}
+
+			if diff := cmp.Diff(tc.want.cfg, cfg); diff != "" {
+				t.Errorf("%s\nLoadConfig(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGenerateAll(t *testing.T) {
+	templates, err := filepath.Abs(filepath.Join("..", "..", "templates"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+
+	crd := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.storage.example.io
+spec:
+  group: storage.example.io
+  names:
+    kind: Bucket
+    plural: buckets
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forProvider:
+                type: object
+                properties:
+                  labels:
+                    type: object
+`
+
+	// A kind that keeps labels as a list of key/value objects
+	listCRD := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: pools.compute.example.io
+spec:
+  group: compute.example.io
+  names:
+    kind: Pool
+    plural: pools
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forProvider:
+                type: object
+                properties:
+                  labels:
+                    type: array
+                    items:
+                      type: object
+                      properties:
+                        key:
+                          type: string
+                        value:
+                          type: string
+`
+
+	upstream := newTestRepository(t)
+	commit := upstream.Commit(map[string]string{"package/crds/bucket.yaml": crd, "package/crds/pool.yaml": listCRD})
+	upstream.Tag("v0.1.0", commit)
+
+	config := `
+aggregate:
+  output: zz_filters.go
+  template: ` + filepath.Join(templates, "filters.tmpl") + `
+outputs:
+- format: json
+  output: filters.json
+- format: markdown
+  output: coverage.md
+providers:
+- name: provider-example
+  prefix: Example
+  source: ` + upstream.Path + `
+  ref: v0.1.0
+  groupSuffix: example.io
+  tagFieldPaths: [spec.forProvider.labels]
+  template: ` + filepath.Join(templates, "provider.tmpl") + `
+`
+	if err := os.WriteFile(filepath.Join(root, "providers.yaml"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(filepath.Join(root, "providers.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig(...): %v", err)
+	}
+
+	if err := GenerateAll(logging.NewNopLogger(), cfg); err != nil {
+		t.Fatalf("GenerateAll(...): %v", err)
+	}
+
+	want := map[string][]string{
+		"zz_provider-example.go": {
+			"// Version:      v0.1.0",
+			"func NewExampleResourceFilter() ResourceFilter {",
+			`"storage.example.io/Bucket": true,`,
+			`"compute.example.io/Pool":   true,`,
+			`{Desired: "spec.forProvider.labels", Observed: "status.atProvider.labels", Shape: TagShapeKeyValueList},`,
+			`Version:     "v0.1.0",`,
+			`Commit:      "` + commit + `",`,
+		},
+		"zz_filters.go": {
+			"// Code generated by cmd/generator from providers.yaml. DO NOT EDIT.",
+			"maps.Copy(all, NewExampleResourceFilter())",
+			`"example.io": {`,
+			`Desired:  "spec.forProvider.labels",`,
+			"NewExampleProvenance(),",
+		},
+		"filters.json": {
+			`"groupKind": "storage.example.io/Bucket",`,
+			`"version": "v0.1.0",`,
+		},
+		"coverage.md": {
+			"| provider-example | v0.1.0 | 2 | 2 | 0 |",
+			"| `storage.example.io` | 1 | 1 | 0 |",
+		},
+	}
+
+	for file, lines := range want {
+		bs, err := os.ReadFile(filepath.Join(root, file)) //nolint:gosec // The file is in a test directory.
+		if err != nil {
+			t.Fatalf("GenerateAll(...): cannot read %s: %v", file, err)
+		}
+
+		for _, l := range lines {
+			if !strings.Contains(string(bs), l) {
+				t.Errorf("GenerateAll(...): want %s to contain %q, got:\n%s", file, l, bs)
+			}
+		}
+	}
+}
@@ -2,33 +2,93 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"io"
 	"io/fs"
 	"path/filepath"
+	"slices"
 	"sort"
 
 	"github.com/crossplane-contrib/function-tag-manager/cmd/generator/render"
+	"github.com/crossplane-contrib/function-tag-manager/filters/crd"
 	"github.com/go-git/go-billy/v6"
 	"github.com/go-git/go-billy/v6/util"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	"sigs.k8s.io/yaml"
 
 	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
 )
 
-const (
-	fieldForProvider = "forProvider"
-	fieldSpec        = "spec"
-	fieldTags        = "tags"
-)
+// ScanSummary summarizes the problems of a scan of CRD files.
+type ScanSummary struct {
+	// Errors of the files and documents that could not be examined. They
+	// are only collected when the scan continues on errors.
+	Errors []FileError
+	// Duplicates are the kinds defined by several CRDs.
+	Duplicates []Duplicate
+}
+
+// Conflicts returns the number of kinds defined with conflicting verdicts.
+func (s ScanSummary) Conflicts() int {
+	n := 0
+
+	for _, d := range s.Duplicates {
+		if d.Conflicting {
+			n++
+		}
+	}
+
+	return n
+}
+
+// FileError is the error of a CRD file.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+// Duplicate is a kind defined by several CRDs.
+type Duplicate struct {
+	GroupKind string
+	// Files of the definitions, in the order they were found. The verdict of
+	// the first definition is kept.
+	Files []string
+	// Conflicting is true if the verdicts of the definitions differ.
+	Conflicting bool
+}
+
+// ExamineFieldFromCRDVersions walks a directory of CRDs and determines if
+// one of the tag field paths, like spec.forProvider.tags, holds tags. Each kind
+// gets the verdict of its storage version, and each other served version
+// whose verdict differs gets an entry of its own.
+func ExamineFieldFromCRDVersions(f billy.Filesystem, root string, tagFieldPaths []string) (render.FilterList, error) {
+	filter, _, err := ExamineCRDs(f, root, tagFieldPaths, false)
+	return filter, err
+}
+
+// ExamineCRDs walks a directory of CRDs like ExamineFieldFromCRDVersions.
+// Files may contain several YAML documents, and documents that are not
+// CRDs are skipped. A kind defined several times keeps its first verdict,
+// and definitions with conflicting verdicts are an error. If
+// continueOnError is set, errors of files, documents and conflicting
+// definitions are collected in the summary instead of stopping the walk.
+func ExamineCRDs(f billy.Filesystem, root string, tagFieldPaths []string, continueOnError bool) (render.FilterList, ScanSummary, error) {
+	s := &crdScan{
+		tagFieldPaths:   tagFieldPaths,
+		continueOnError: continueOnError,
+		kinds:           make(map[string]*scannedKind),
+	}
 
-// ExamineFieldFromCRDVersions walks a directory of CRDs and
-// determines if a field exists.
-func ExamineFieldFromCRDVersions(f billy.Filesystem, root string) (render.FilterList, error) {
-	filter := render.FilterList{}
 	err := util.Walk(f, root, func(path string, info fs.FileInfo, e error) error {
 		if e != nil {
-			return e
+			// Nothing can be scanned without the root
+			if path == root {
+				return e
+			}
+
+			return s.fail(path, e)
 		}
 
 		if info.IsDir() {
@@ -40,84 +100,254 @@ func ExamineFieldFromCRDVersions(f billy.Filesystem, root string) (render.Filter
 			return nil
 		}
 
-		var u metav1.TypeMeta
+		return s.examineFile(f, path)
+	})
+	if err != nil {
+		return nil, ScanSummary{}, err
+	}
+
+	filter := render.FilterList{}
 
-		bs, err := util.ReadFile(f, path)
-		if err != nil {
-			return errors.Wrapf(err, "failed to read file %q", path)
-		}
+	for groupKind, k := range s.kinds {
+		filter = append(filter, k.filters...)
 
-		err = yaml.Unmarshal(bs, &u)
-		if err != nil {
-			return errors.Wrapf(err, "failed to parse file %q", path)
+		if len(k.files) > 1 {
+			s.summary.Duplicates = append(s.summary.Duplicates, Duplicate{GroupKind: groupKind, Files: k.files, Conflicting: k.conflicting})
 		}
+	}
 
-		if u.GroupVersionKind().Kind == "CustomResourceDefinition" {
-			var crd extv1.CustomResourceDefinition
+	sort.Slice(filter, func(i, j int) bool {
+		return filter[i].Key() < filter[j].Key()
+	})
 
-			err := yaml.Unmarshal(bs, &crd)
-			if err != nil {
-				return errors.Wrapf(err, "failed to unmarshal CRD file %q", path)
-			}
+	sort.Slice(s.summary.Duplicates, func(i, j int) bool {
+		return s.summary.Duplicates[i].GroupKind < s.summary.Duplicates[j].GroupKind
+	})
 
-			storedVersion, err := GetCRDVersion(crd)
-			if err != nil {
-				return errors.Wrapf(err, "failed to determine CRD version %q", path)
-			}
+	return filter, s.summary, nil
+}
 
-			schema := storedVersion.Schema
-			// Look for the field at fieldpath "spec.forProvider.tags"
-			if schema != nil && schema.OpenAPIV3Schema != nil {
-				key := crd.Spec.Group + "/" + crd.Spec.Names.Kind
-				hasField := checkFieldPath(schema.OpenAPIV3Schema, []string{fieldSpec, fieldForProvider, fieldTags})
-				filter = append(filter, render.Filter{GroupKind: key, Enabled: hasField})
-			}
-		}
+// crdScan is the state of a walk of CRD files.
+type crdScan struct {
+	tagFieldPaths   []string
+	continueOnError bool
+	kinds           map[string]*scannedKind
+	summary         ScanSummary
+}
 
-		return nil
-	})
+// scannedKind is a kind found by a scan.
+type scannedKind struct {
+	filters     render.FilterList
+	files       []string
+	conflicting bool
+}
 
-	sort.Slice(filter, func(i, j int) bool {
-		return filter[i].GroupKind < filter[j].GroupKind
+// fail returns the error of a file, or records it if the scan continues on
+// errors.
+func (s *crdScan) fail(path string, err error) error {
+	if err == nil || !s.continueOnError {
+		return err
+	}
+
+	s.summary.Errors = append(s.summary.Errors, FileError{Path: path, Err: err})
+
+	return nil
+}
+
+// examineFile examines the CRDs of each document of a file.
+func (s *crdScan) examineFile(f billy.Filesystem, path string) error {
+	file, err := f.Open(path)
+	if err != nil {
+		return s.fail(path, errors.Wrapf(err, "failed to read file %q", path))
+	}
+
+	defer func() { _ = file.Close() }()
+
+	var docErr error
+
+	err = decodeYAMLDocuments(file, func(i int, doc []byte) error {
+		docErr = s.fail(path, s.examineDocument(path, i, doc))
+		return docErr
 	})
+	if docErr != nil {
+		return docErr
+	}
 
-	return filter, err
+	return s.fail(path, errors.Wrapf(err, "failed to read file %q", path))
+}
+
+// examineDocument examines a document of a file, if it is a CRD.
+func (s *crdScan) examineDocument(path string, i int, doc []byte) error {
+	var obj any
+	if err := yaml.Unmarshal(doc, &obj); err != nil {
+		return errors.Wrapf(err, "failed to parse file %q, document %d", path, i)
+	}
+
+	// Documents that are not objects, like lists, are not CRDs
+	if m, ok := obj.(map[string]any); !ok || m["kind"] != "CustomResourceDefinition" {
+		return nil
+	}
+
+	var c extv1.CustomResourceDefinition
+	if err := yaml.Unmarshal(doc, &c); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal CRD file %q, document %d", path, i)
+	}
+
+	filters, err := examineCRD(c, s.tagFieldPaths)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine CRD version %q", path)
+	}
+
+	if len(filters) == 0 {
+		return nil
+	}
+
+	groupKind := filters[0].GroupKind
+
+	k, ok := s.kinds[groupKind]
+	if !ok {
+		s.kinds[groupKind] = &scannedKind{filters: filters, files: []string{path}}
+		return nil
+	}
+
+	k.files = append(k.files, path)
+
+	if sameVerdicts(k.filters, filters) {
+		return nil
+	}
+
+	k.conflicting = true
+
+	// Conflicts are reported with the duplicates of the summary
+	if s.continueOnError {
+		return nil
+	}
+
+	return errors.Errorf("kind %q of file %q conflicts with its definition in %q", groupKind, path, k.files[0])
 }
 
-// checkFieldPath traverses the OpenAPI schema to check if a field path exists.
-func checkFieldPath(schema *extv1.JSONSchemaProps, path []string) bool {
-	if schema == nil || len(path) == 0 {
-		return false
+// examineCRD returns the filter of the kind of a CRD, which has the verdict
+// of its storage version, followed by the filters of the served versions
+// whose verdict or tag paths differ. It returns no filters if the storage
+// version has no schema.
+func examineCRD(c extv1.CustomResourceDefinition, tagFieldPaths []string) (render.FilterList, error) {
+	storedVersion, err := crd.GetCRDVersion(c)
+	if err != nil {
+		return nil, err
 	}
-	// Get the first element of the path
-	field := path[0]
 
-	// Check if the field exists in the schema properties
-	if schema.Properties == nil {
-		return false
+	schema := storedVersion.Schema
+	if schema == nil || schema.OpenAPIV3Schema == nil {
+		return nil, nil
 	}
 
-	property, exists := schema.Properties[field]
-	if !exists {
-		return false
+	key := c.Spec.Group + "/" + c.Spec.Names.Kind
+	v := crd.ExaminePaths(schema.OpenAPIV3Schema, tagFieldPaths)
+	f := render.Filter{GroupKind: key, Enabled: v.Enabled, TagPaths: renderTagPaths(v)}
+
+	for _, tf := range crd.FindTagFields(schema.OpenAPIV3Schema, crd.InventoryFields(tagFieldNames(tagFieldPaths)...)) {
+		f.TagFields = append(f.TagFields, render.TagField{
+			Path:  tf.Path,
+			Shape: string(tf.Shape),
+		})
 	}
 
-	// If this is the last element in the path, we found it
-	if len(path) == 1 {
-		return property.Type == "object"
+	filters := render.FilterList{f}
+
+	// Served versions whose tag support or tag paths differ from the storage
+	// version get their own entry
+	for _, sv := range crd.GetServedVersions(c) {
+		if sv.Name == storedVersion.Name || sv.Schema == nil || sv.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+
+		vv := crd.ExaminePaths(sv.Schema.OpenAPIV3Schema, tagFieldPaths)
+		paths := renderTagPaths(vv)
+
+		if vv.Enabled == v.Enabled && slices.Equal(paths, f.TagPaths) {
+			continue
+		}
+
+		// A version that keeps tags in a map at spec.forProvider.tags must
+		// not inherit the tag paths of its kind
+		if vv.Enabled && len(paths) == 0 && len(f.TagPaths) > 0 {
+			paths = []render.TagPath{{
+				Desired:  crd.FieldSpec + "." + crd.FieldForProvider + "." + crd.FieldTags,
+				Observed: crd.FieldStatus + "." + crd.FieldAtProvider + "." + crd.FieldTags,
+				Shape:    render.TagShapeMap,
+			}}
+		}
+
+		filters = append(filters, render.Filter{GroupKind: key, APIVersion: sv.Name, Enabled: vv.Enabled, TagPaths: paths})
 	}
 
-	// Otherwise, recurse into the next level
-	return checkFieldPath(&property, path[1:])
+	sort.Slice(filters[1:], func(i, j int) bool {
+		return filters[i+1].APIVersion < filters[j+1].APIVersion
+	})
+
+	return filters, nil
 }
 
-// GetCRDVersion returns the Stored and Served version of the CRD.
-func GetCRDVersion(crd extv1.CustomResourceDefinition) (extv1.CustomResourceDefinitionVersion, error) {
-	for _, version := range crd.Spec.Versions {
-		if version.Served && version.Storage {
-			return version, nil
+// tagFieldNames returns the names of the fields of tag field paths, like
+// labels for spec.forProvider.labels.
+func tagFieldNames(tagFieldPaths []string) []string {
+	names := make([]string, 0, len(tagFieldPaths))
+
+	for _, p := range tagFieldPaths {
+		if fields, err := crd.ParseTagFieldPath(p); err == nil {
+			names = append(names, fields[len(fields)-1])
 		}
 	}
 
-	return extv1.CustomResourceDefinitionVersion{}, errors.New("no served and storage version found in CustomResourceDefinition")
+	return names
+}
+
+// renderTagPaths returns the tag paths of a verdict for a template.
+func renderTagPaths(v crd.Verdict) []render.TagPath {
+	var rendered []render.TagPath
+
+	for _, p := range v.TagPaths {
+		rendered = append(rendered, render.TagPath{
+			Desired:  p.Desired,
+			Observed: p.Observed,
+			Shape:    string(p.GetShape()),
+		})
+	}
+
+	return rendered
+}
+
+// sameVerdicts returns true if two definitions of a kind have the same
+// entries, tag support and tag paths.
+func sameVerdicts(a, b render.FilterList) bool {
+	return slices.EqualFunc(a, b, func(x, y render.Filter) bool {
+		return x.Key() == y.Key() && x.Enabled == y.Enabled && slices.Equal(x.TagPaths, y.TagPaths)
+	})
+}
+
+// decodeYAMLDocuments calls fn with the index and content of each non-empty
+// document of a multi-document YAML stream, reading one document at a time.
+func decodeYAMLDocuments(r io.Reader, fn func(i int, doc []byte) error) error {
+	yr := utilyaml.NewYAMLReader(bufio.NewReader(r))
+
+	for i := 0; ; {
+		doc, err := yr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		if err := fn(i, doc); err != nil {
+			return err
+		}
+
+		i++
+	}
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/crossplane-contrib/function-tag-manager/cmd/generator/render"
@@ -8,203 +9,8 @@ import (
 	"github.com/go-git/go-billy/v6/util"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
-	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 )
 
-func TestCheckFieldPath(t *testing.T) {
-	type args struct {
-		schema *extv1.JSONSchemaProps
-		path   []string
-	}
-
-	type want struct {
-		result bool
-	}
-
-	cases := map[string]struct {
-		reason string
-		args   args
-		want   want
-	}{
-		"NilSchema": {
-			reason: "Should return false for nil schema",
-			args: args{
-				schema: nil,
-				path:   []string{fieldSpec, fieldForProvider, fieldTags},
-			},
-			want: want{
-				result: false,
-			},
-		},
-		"EmptyPath": {
-			reason: "Should return false for empty path",
-			args: args{
-				schema: &extv1.JSONSchemaProps{
-					Properties: map[string]extv1.JSONSchemaProps{
-						fieldSpec: {},
-					},
-				},
-				path: []string{},
-			},
-			want: want{
-				result: false,
-			},
-		},
-		"NilProperties": {
-			reason: "Should return false when schema has nil properties",
-			args: args{
-				schema: &extv1.JSONSchemaProps{
-					Properties: nil,
-				},
-				path: []string{fieldSpec},
-			},
-			want: want{
-				result: false,
-			},
-		},
-		"SingleLevelFieldExists": {
-			reason: "Should return true when single level field exists",
-			args: args{
-				schema: &extv1.JSONSchemaProps{
-					Properties: map[string]extv1.JSONSchemaProps{
-						fieldSpec: {Type: "object"},
-					},
-				},
-				path: []string{fieldSpec},
-			},
-			want: want{
-				result: true,
-			},
-		},
-		"SingleLevelFieldDoesNotExist": {
-			reason: "Should return false when single level field does not exist",
-			args: args{
-				schema: &extv1.JSONSchemaProps{
-					Properties: map[string]extv1.JSONSchemaProps{
-						fieldSpec: {},
-					},
-				},
-				path: []string{"status"},
-			},
-			want: want{
-				result: false,
-			},
-		},
-		"NestedPathExists": {
-			reason: "Should return true when nested path exists",
-			args: args{
-				schema: &extv1.JSONSchemaProps{
-					Properties: map[string]extv1.JSONSchemaProps{
-						fieldSpec: {
-							Properties: map[string]extv1.JSONSchemaProps{
-								fieldForProvider: {
-									Properties: map[string]extv1.JSONSchemaProps{
-										fieldTags: {Type: "object"},
-									},
-								},
-							},
-						},
-					},
-				},
-				path: []string{fieldSpec, fieldForProvider, fieldTags},
-			},
-			want: want{
-				result: true,
-			},
-		},
-		"NestedPathPartiallyExists": {
-			reason: "Should return false when nested path partially exists",
-			args: args{
-				schema: &extv1.JSONSchemaProps{
-					Properties: map[string]extv1.JSONSchemaProps{
-						fieldSpec: {
-							Properties: map[string]extv1.JSONSchemaProps{
-								fieldForProvider: {},
-							},
-						},
-					},
-				},
-				path: []string{fieldSpec, fieldForProvider, fieldTags},
-			},
-			want: want{
-				result: false,
-			},
-		},
-		"NestedPathDoesNotExistAtFirstLevel": {
-			reason: "Should return false when first level of nested path does not exist",
-			args: args{
-				schema: &extv1.JSONSchemaProps{
-					Properties: map[string]extv1.JSONSchemaProps{
-						"status": {},
-					},
-				},
-				path: []string{fieldSpec, fieldForProvider, fieldTags},
-			},
-			want: want{
-				result: false,
-			},
-		},
-		"NestedPathDoesNotExistAtMiddleLevel": {
-			reason: "Should return false when middle level of nested path does not exist",
-			args: args{
-				schema: &extv1.JSONSchemaProps{
-					Properties: map[string]extv1.JSONSchemaProps{
-						fieldSpec: {
-							Properties: map[string]extv1.JSONSchemaProps{
-								"initProvider": {},
-							},
-						},
-					},
-				},
-				path: []string{fieldSpec, fieldForProvider, fieldTags},
-			},
-			want: want{
-				result: false,
-			},
-		},
-		"DeepNestedPath": {
-			reason: "Should handle deep nested paths correctly",
-			args: args{
-				schema: &extv1.JSONSchemaProps{
-					Properties: map[string]extv1.JSONSchemaProps{
-						fieldSpec: {
-							Properties: map[string]extv1.JSONSchemaProps{
-								fieldForProvider: {
-									Properties: map[string]extv1.JSONSchemaProps{
-										"config": {
-											Properties: map[string]extv1.JSONSchemaProps{
-												"settings": {
-													Properties: map[string]extv1.JSONSchemaProps{
-														fieldTags: {Type: "object"},
-													},
-												},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-				path: []string{fieldSpec, fieldForProvider, "config", "settings", fieldTags},
-			},
-			want: want{
-				result: true,
-			},
-		},
-	}
-
-	for name, tc := range cases {
-		t.Run(name, func(t *testing.T) {
-			got := checkFieldPath(tc.args.schema, tc.args.path)
-
-			if got != tc.want.result {
-				t.Errorf("%s\ncheckFieldPath(): want %v, got %v", tc.reason, tc.want.result, got)
-			}
-		})
-	}
-}
-
 func TestExamineFieldFromCRDVersions(t *testing.T) {
 	const testRootDir = "package/crds"
 
@@ -325,6 +131,17 @@ spec:
                     type: object
 `
 
+	// A list of key/value tags at spec.forProvider.tag
+	tagListProperty := `tag:
+                    type: array
+                    items:
+                      type: object
+                      properties:
+                        key:
+                          type: string
+                        value:
+                          type: string`
+
 	// CRD with spec.forProvider but no tags
 	crdWithForProviderNoTags := `apiVersion: apiextensions.k8s.io/v1
 kind: CustomResourceDefinition
@@ -385,11 +202,193 @@ spec:
                     type: array
 `
 
+	// CRD with tags as a list of key/value objects
+	crdWithKeyValueListTags := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: autoscalinggroups.autoscaling.aws.upbound.io
+spec:
+  group: autoscaling.aws.upbound.io
+  names:
+    kind: AutoscalingGroup
+    plural: autoscalinggroups
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forProvider:
+                type: object
+                properties:
+                  tag:
+                    type: array
+                    items:
+                      type: object
+                      properties:
+                        key:
+                          type: string
+                        propagateAtLaunch:
+                          type: boolean
+                        value:
+                          type: string
+`
+
+	// CRD with a single key/value tag
+	crdWithSingleKeyValueTag := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: grouptags.autoscaling.aws.upbound.io
+spec:
+  group: autoscaling.aws.upbound.io
+  names:
+    kind: GroupTag
+    plural: grouptags
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forProvider:
+                type: object
+                properties:
+                  tag:
+                    type: array
+                    maxItems: 1
+                    items:
+                      type: object
+                      properties:
+                        key:
+                          type: string
+                        value:
+                          type: string
+`
+
+	// CRD with labels field
+	crdWithLabels := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.storage.gcp.upbound.io
+spec:
+  group: storage.gcp.upbound.io
+  names:
+    kind: Bucket
+    plural: buckets
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forProvider:
+                type: object
+                properties:
+                  labels:
+                    type: object
+`
+
+	// CRD with nested tag maps, other tag maps like volumeTags, and fields
+	// named tags that are not tags
+	crdWithNestedTags := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: instances.ec2.aws.upbound.io
+spec:
+  group: ec2.aws.upbound.io
+  names:
+    kind: Instance
+    plural: instances
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forProvider:
+                type: object
+                properties:
+                  tags:
+                    type: object
+                    additionalProperties:
+                      type: string
+                  volumeTags:
+                    type: object
+                    additionalProperties:
+                      type: string
+                  rootBlockDevice:
+                    type: array
+                    maxItems: 1
+                    items:
+                      type: object
+                      properties:
+                        tags:
+                          type: object
+                          additionalProperties:
+                            type: string
+                  ebsBlockDevice:
+                    type: array
+                    items:
+                      type: object
+                      properties:
+                        tags:
+                          type: object
+                          additionalProperties:
+                            type: string
+                  instanceMarketOptions:
+                    type: object
+                    properties:
+                      tags:
+                        type: string
+              initProvider:
+                type: object
+                properties:
+                  tags:
+                    type: object
+                    additionalProperties:
+                      type: string
+          status:
+            type: object
+            properties:
+              atProvider:
+                type: object
+                properties:
+                  tags:
+                    type: object
+                    additionalProperties:
+                      type: string
+`
+
 	type testCase struct {
-		reason string
-		files  map[string]string
-		want   render.FilterList
-		errStr string
+		reason        string
+		tagFieldPaths []string
+		files         map[string]string
+		want          render.FilterList
+		errStr        string
 	}
 
 	cases := map[string]testCase{
@@ -441,6 +440,36 @@ spec:
 				{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
 			},
 		},
+		"MultiDocumentFile": {
+			reason: "Should examine every CRD of a multi-document file, and skip documents that are not CRDs",
+			files: map[string]string{
+				"bundle.yaml": crdWithTags + "---\n" + notACRD + "---\n- not\n- an object\n---\n" + crdWithoutTags,
+			},
+			want: render.FilterList{
+				{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+				{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+			},
+		},
+		"DuplicateKind": {
+			reason: "Should keep a single entry of a kind defined by several CRDs with the same verdict",
+			files: map[string]string{
+				"bucket.yaml":  crdWithTags,
+				"bundle.yaml":  "---\n" + crdWithTags,
+				"another.yaml": crdWithoutTags,
+			},
+			want: render.FilterList{
+				{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+				{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+			},
+		},
+		"ConflictingDuplicateKind": {
+			reason: "Should return error for a kind defined by several CRDs with conflicting verdicts",
+			files: map[string]string{
+				"bucket.yaml": crdWithTags,
+				"bundle.yaml": strings.Replace(crdWithTags, "tags:", "notTags:", 1),
+			},
+			errStr: "conflicts with its definition",
+		},
 		"InvalidYAML": {
 			reason: "Should return error for invalid YAML",
 			files: map[string]string{
@@ -449,12 +478,74 @@ spec:
 			errStr: "failed to parse file",
 		},
 		"MultipleVersionsUsesStoredVersion": {
-			reason: "Should use the stored version when multiple versions exist",
+			reason: "Should use the stored version for the kind, and add served versions whose tag support differs",
 			files: map[string]string{
 				"role.yaml": crdMultipleVersions,
 			},
 			want: render.FilterList{
 				{GroupKind: "iam.aws.upbound.io/Role", Enabled: true},
+				{GroupKind: "iam.aws.upbound.io/Role", APIVersion: "v1beta1", Enabled: false},
+			},
+		},
+		"MultipleVersionsUnserved": {
+			reason: "Should ignore versions that are not served",
+			files: map[string]string{
+				"role.yaml": strings.Replace(crdMultipleVersions, "served: true\n    storage: false", "served: false\n    storage: false", 1),
+			},
+			want: render.FilterList{
+				{GroupKind: "iam.aws.upbound.io/Role", Enabled: true},
+			},
+		},
+		"MultipleVersionsAgree": {
+			reason: "Should not add served versions whose tag support matches the stored version",
+			files: map[string]string{
+				"role.yaml": strings.Replace(crdMultipleVersions, "notTags:", "tags:", 1),
+			},
+			want: render.FilterList{
+				{GroupKind: "iam.aws.upbound.io/Role", Enabled: true},
+			},
+		},
+		"MultipleVersionsTagPaths": {
+			reason: "Should add served versions whose tag paths differ from the stored version, with their tag paths",
+			files: map[string]string{
+				"role.yaml": strings.Replace(crdMultipleVersions, "notTags:\n                    type: object", tagListProperty, 1),
+			},
+			want: render.FilterList{
+				{GroupKind: "iam.aws.upbound.io/Role", Enabled: true},
+				{
+					GroupKind:  "iam.aws.upbound.io/Role",
+					APIVersion: "v1beta1",
+					Enabled:    true,
+					TagPaths: []render.TagPath{
+						{Desired: "spec.forProvider.tag", Observed: "status.atProvider.tag", Shape: render.TagShapeKeyValueList},
+					},
+				},
+			},
+		},
+		"MultipleVersionsMapTagPaths": {
+			reason: "Should give served versions that keep tags in a map the map tag path, when the stored version has other tag paths",
+			files: map[string]string{
+				"role.yaml": strings.Replace(strings.Replace(crdMultipleVersions, "tags:\n                    type: object", tagListProperty, 1), "notTags:", "tags:", 1),
+			},
+			want: render.FilterList{
+				{
+					GroupKind: "iam.aws.upbound.io/Role",
+					Enabled:   true,
+					TagPaths: []render.TagPath{
+						{Desired: "spec.forProvider.tag", Observed: "status.atProvider.tag", Shape: render.TagShapeKeyValueList},
+					},
+					TagFields: []render.TagField{
+						{Path: "spec.forProvider.tag", Shape: render.TagShapeKeyValueList},
+					},
+				},
+				{
+					GroupKind:  "iam.aws.upbound.io/Role",
+					APIVersion: "v1beta1",
+					Enabled:    true,
+					TagPaths: []render.TagPath{
+						{Desired: "spec.forProvider.tags", Observed: "status.atProvider.tags", Shape: render.TagShapeMap},
+					},
+				},
 			},
 		},
 		"CRDWithForProviderNoTags": {
@@ -495,6 +586,67 @@ spec:
 				{GroupKind: "apimanagement.azure.upbound.io/NamedValue", Enabled: false},
 			},
 		},
+		"LabelsField": {
+			reason:        "Should look for the tag field paths, like spec.forProvider.labels for GCP",
+			tagFieldPaths: []string{"spec.forProvider.labels"},
+			files: map[string]string{
+				"bucket.yaml":     crdWithLabels,
+				"bucket-aws.yaml": crdWithTags,
+			},
+			want: render.FilterList{
+				{
+					GroupKind: "storage.gcp.upbound.io/Bucket",
+					Enabled:   true,
+					TagPaths: []render.TagPath{
+						{Desired: "spec.forProvider.labels", Observed: "status.atProvider.labels", Shape: render.TagShapeMap},
+					},
+				},
+				{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: false},
+			},
+		},
+		"KeyValueListTags": {
+			reason: "Should identify tags that are a list of key/value objects, but not a single key/value tag",
+			files: map[string]string{
+				"autoscalinggroup.yaml": crdWithKeyValueListTags,
+				"grouptag.yaml":         crdWithSingleKeyValueTag,
+			},
+			want: render.FilterList{
+				{
+					GroupKind: "autoscaling.aws.upbound.io/AutoscalingGroup",
+					Enabled:   true,
+					TagPaths: []render.TagPath{
+						{Desired: "spec.forProvider.tag", Observed: "status.atProvider.tag", Shape: render.TagShapeKeyValueList},
+					},
+					TagFields: []render.TagField{
+						{Path: "spec.forProvider.tag", Shape: render.TagShapeKeyValueList},
+					},
+				},
+				{GroupKind: "autoscaling.aws.upbound.io/GroupTag", Enabled: false},
+			},
+		},
+		"TagFieldInventory": {
+			reason: "Should record every field of the spec that holds a map of strings or a list of key/value tags, and the tag paths of the other tag maps of spec.forProvider",
+			files: map[string]string{
+				"instance.yaml": crdWithNestedTags,
+			},
+			want: render.FilterList{
+				{
+					GroupKind: "ec2.aws.upbound.io/Instance",
+					Enabled:   true,
+					TagPaths: []render.TagPath{
+						{Desired: "spec.forProvider.tags", Observed: "status.atProvider.tags", Shape: render.TagShapeMap},
+						{Desired: "spec.forProvider.rootBlockDevice[0].tags", Observed: "status.atProvider.rootBlockDevice[0].tags", Shape: render.TagShapeMap},
+						{Desired: "spec.forProvider.volumeTags", Observed: "status.atProvider.volumeTags", Shape: render.TagShapeMap},
+					},
+					TagFields: []render.TagField{
+						{Path: "spec.forProvider.ebsBlockDevice[].tags", Shape: render.TagShapeMap},
+						{Path: "spec.forProvider.rootBlockDevice[].tags", Shape: render.TagShapeMap},
+						{Path: "spec.forProvider.tags", Shape: render.TagShapeMap},
+						{Path: "spec.initProvider.tags", Shape: render.TagShapeMap},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range cases {
@@ -519,7 +671,12 @@ spec:
 			}
 
 			// Run the function
-			got, err := ExamineFieldFromCRDVersions(fs, testRootDir)
+			tagFieldPaths := tc.tagFieldPaths
+			if len(tagFieldPaths) == 0 {
+				tagFieldPaths = []string{defaultTagFieldPath}
+			}
+
+			got, err := ExamineFieldFromCRDVersions(fs, testRootDir, tagFieldPaths)
 
 			// Check error
 			if tc.errStr != "" {
@@ -556,3 +713,118 @@ spec:
 		})
 	}
 }
+
+func TestExamineCRDs(t *testing.T) {
+	const root = "crds"
+
+	type args struct {
+		files           map[string]string
+		continueOnError bool
+	}
+
+	type want struct {
+		filter     render.FilterList
+		errorFiles []string
+		duplicates []Duplicate
+		err        bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"StopOnError": {
+			reason: "A file that cannot be parsed stops the scan",
+			args: args{
+				files: map[string]string{
+					"bucket.yaml":  sourceBucketCRD,
+					"invalid.yaml": "this is not valid: yaml: at all: [\n",
+				},
+			},
+			want: want{err: true},
+		},
+		"ContinueOnError": {
+			reason: "Errors of files and documents are collected in the summary, and the other CRDs are examined",
+			args: args{
+				files: map[string]string{
+					"bucket.yaml":  sourceBucketCRD,
+					"invalid.yaml": "this is not valid: yaml: at all: [\n",
+					"bundle.yaml":  "kind: CustomResourceDefinition\nspec: []\n---\n" + sourceCertificateCRD,
+				},
+				continueOnError: true,
+			},
+			want: want{
+				filter: render.FilterList{
+					{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+					{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+				},
+				errorFiles: []string{"crds/bundle.yaml", "crds/invalid.yaml"},
+			},
+		},
+		"ConflictingDuplicates": {
+			reason: "Kinds defined with conflicting verdicts keep their first definition, and are reported in the summary",
+			args: args{
+				files: map[string]string{
+					"bucket.yaml":      sourceBucketCRD,
+					"bundle.yaml":      strings.Replace(sourceBucketCRD, "tags:", "region:", 1) + "---\n" + sourceCertificateCRD,
+					"certificate.yaml": sourceCertificateCRD,
+				},
+				continueOnError: true,
+			},
+			want: want{
+				filter: render.FilterList{
+					{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+					{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+				},
+				errorFiles: []string{},
+				duplicates: []Duplicate{
+					{GroupKind: "acmpca.aws.upbound.io/Certificate", Files: []string{"crds/bundle.yaml", "crds/certificate.yaml"}},
+					{GroupKind: "s3.aws.upbound.io/Bucket", Files: []string{"crds/bucket.yaml", "crds/bundle.yaml"}, Conflicting: true},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := memfs.New()
+
+			for name, content := range tc.args.files {
+				if err := util.WriteFile(fs, root+"/"+name, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			filter, summary, err := ExamineCRDs(fs, root, []string{defaultTagFieldPath}, tc.args.continueOnError)
+			if tc.want.err {
+				if err == nil {
+					t.Errorf("%s\nExamineCRDs(...): want error, got nil", tc.reason)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("%s\nExamineCRDs(...): unexpected error: %v", tc.reason, err)
+			}
+
+			if diff := cmp.Diff(tc.want.filter, filter); diff != "" {
+				t.Errorf("%s\nExamineCRDs(...): -want filter, +got filter:\n%s", tc.reason, diff)
+			}
+
+			errorFiles := make([]string, 0, len(summary.Errors))
+			for _, e := range summary.Errors {
+				errorFiles = append(errorFiles, e.Path)
+			}
+
+			if diff := cmp.Diff(tc.want.errorFiles, errorFiles); diff != "" {
+				t.Errorf("%s\nExamineCRDs(...): -want error files, +got error files:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.duplicates, summary.Duplicates); diff != "" {
+				t.Errorf("%s\nExamineCRDs(...): -want duplicates, +got duplicates:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
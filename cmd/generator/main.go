@@ -2,18 +2,21 @@ package main
 
 // Clone a Provider repo and extract the CRD manifests.
 import (
+	"bytes"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/crossplane-contrib/function-tag-manager/cmd/generator/render"
 	"github.com/crossplane/function-sdk-go"
 	"github.com/go-git/go-billy/v6"
-	"github.com/go-git/go-billy/v6/osfs"
+	"github.com/go-git/go-billy/v6/util"
 	git "github.com/go-git/go-git/v6"
 	"github.com/go-git/go-git/v6/plumbing"
-	"github.com/go-git/go-git/v6/plumbing/cache"
 	"github.com/go-git/go-git/v6/storage"
-	"github.com/go-git/go-git/v6/storage/filesystem"
 
 	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
@@ -23,12 +26,24 @@ import (
 type CLI struct {
 	Debug bool `help:"Emit debug logs in addition to info logs." short:"d"`
 
-	RepositoryDir           string `help:"local git repository cache" default:"_work/providers/provider-upjet-aws"`
-	RepoURL                 string `help:"Git repo to clone" default:"https://github.com/crossplane-contrib/provider-upjet-aws.git"`
-	CrossplanePackageCRDDir string `help:"Location of CRD files" default:"package/crds"`
-	OutputFile              string `help:"file to output generated Go code"`
-	GitBranchOriginMain     string `help:"Git branch to clone." default:"refs/remotes/origin/main"`
-	TemplateFile            string `help:"Go Text Template to use to render filters" default:"templates/aws.tmpl"`
+	RepositoryDir           string       `help:"local git repository cache" default:"_work/providers/provider-upjet-aws"`
+	RepoURL                 string       `help:"Git repo to clone" default:"https://github.com/crossplane-contrib/provider-upjet-aws.git"`
+	CrossplanePackageCRDDir string       `help:"Location of CRD files in the git repository" default:"package/crds"`
+	OutputFile              string       `help:"file to output generated Go code"`
+	GitBranchOriginMain     string       `help:"Git branch to clone." default:"refs/remotes/origin/main"`
+	Ref                     string       `help:"Git tag, commit or reference to scan. Overrides --git-branch-origin-main."`
+	TemplateFile            string       `help:"Go Text Template to use to render filters" default:"templates/provider.tmpl"`
+	TagFieldPaths           []string     `help:"Field paths of spec.forProvider to probe for tags, in order, like spec.forProvider.labels for GCP" default:"spec.forProvider.tags"`
+	ProviderName            string       `help:"Name of the provider, recorded with the versions in which kinds support tags" default:"provider-upjet-aws"`
+	ProviderVersions        []string     `help:"Provider release tags to scan, from the oldest to the latest, to record the versions in which kinds support tags"`
+	ProviderPrefix          string       `help:"Prefix of the generated function names, like AWS" default:"AWS"`
+	Config                  string       `help:"YAML file declaring the providers to generate filters for. Other flags are ignored when set." type:"path"`
+	SourceType              SourceType   `help:"Where to read CRDs from: git, dir, tar or xpkg." default:"git" enum:"git,dir,tar,xpkg"`
+	SourcePath              string       `help:"Local directory, tarball or .xpkg file to read CRDs from, when --source-type is not git." type:"path"`
+	CompareWith             string       `help:"Generated filter file, directory of generated filter files, or Git ref of the provider to compare the scanned filters with. A report of the changed kinds is printed instead of rendering the filters."`
+	ReportFormat            ReportFormat `help:"Format of the --compare-with report: text, markdown or json." default:"text" enum:"text,markdown,json"`
+	Format                  string       `help:"Format of the output: go, rendered with --template-file, or json, yaml or markdown." default:"go" enum:"go,json,yaml,markdown"`
+	ContinueOnError         bool         `help:"Skip CRD files that cannot be examined, and kinds defined by CRDs with conflicting verdicts, and log a summary of them instead of failing."`
 }
 
 // Cloner clones Git repositories.
@@ -50,6 +65,8 @@ type Generator struct {
 	CRDDir        string
 	Logger        logging.Logger
 	RepoDirectory string
+	// ContinueOnError collects the errors of CRD files instead of failing.
+	ContinueOnError bool
 }
 
 func (c *CLI) Run() error {
@@ -58,68 +75,234 @@ func (c *CLI) Run() error {
 		return err
 	}
 
+	if c.Config != "" {
+		cfg, err := LoadConfig(c.Config)
+		if err != nil {
+			return err
+		}
+
+		if c.ContinueOnError {
+			for i := range cfg.Providers {
+				cfg.Providers[i].ContinueOnError = true
+			}
+		}
+
+		if c.CompareWith != "" {
+			return Compare(log, cfg.Providers, c.CompareWith, c.ReportFormat, os.Stdout)
+		}
+
+		return GenerateAll(log, cfg)
+	}
+
 	log.Info("Generating resource filters from CRDs")
-	g := Generator{
-		CRDDir:        c.CrossplanePackageCRDDir,
-		Logger:        log,
-		RepoDirectory: c.RepositoryDir,
+
+	// Local sources contain the CRDs at their root.
+	source, crdDir := c.RepoURL, c.CrossplanePackageCRDDir
+	if c.SourceType != SourceGit {
+		source, crdDir = c.SourcePath, "."
+	}
+
+	ref := c.GitBranchOriginMain
+	if c.Ref != "" {
+		ref = c.Ref
+	}
+
+	p := ProviderConfig{
+		Name:            c.ProviderName,
+		Prefix:          c.ProviderPrefix,
+		SourceType:      c.SourceType,
+		Source:          source,
+		Ref:             ref,
+		RepositoryDir:   c.RepositoryDir,
+		CRDDir:          crdDir,
+		TagFieldPaths:   c.TagFieldPaths,
+		Versions:        c.ProviderVersions,
+		Output:          c.OutputFile,
+		ContinueOnError: c.ContinueOnError,
+	}
+
+	if c.CompareWith != "" {
+		return Compare(log, []ProviderConfig{p}, c.CompareWith, c.ReportFormat, os.Stdout)
 	}
 
-	var w *git.Worktree
+	provider, err := ScanProvider(log, p)
+	if err != nil {
+		return err
+	}
 
-	var bf billy.Filesystem
+	if c.Format != render.FormatGo {
+		return renderFormat(log, c.OutputFile, c.Format, []render.Provider{provider})
+	}
 
-	filesystemfs := osfs.New(c.RepositoryDir)
+	return renderFile(log, c.OutputFile, provider, c.TemplateFile)
+}
 
-	_, err = os.Stat(c.RepositoryDir)
-	if os.IsNotExist(err) {
-		log.Debug("repo does not exist on the filesystem, cloning", "directory", c.RepositoryDir)
+// GenerateAll renders the filters of every provider of a Config, and the
+// file that combines them.
+func GenerateAll(log logging.Logger, cfg *Config) error {
+	providers := make([]render.Provider, 0, len(cfg.Providers))
 
-		storage := filesystem.NewStorage(filesystemfs, cache.NewObjectLRU(cache.DefaultMaxSize))
-		g.Cloner = Cloner{
-			Paths:     []string{c.CrossplanePackageCRDDir},
-			Reference: c.GitBranchOriginMain,
-			RepoURL:   c.RepoURL,
-			Storage:   storage,
-			Worktree:  filesystemfs,
-		}
+	for _, p := range cfg.Providers {
+		log.Info("Generating resource filters from CRDs", "provider", p.Name)
 
-		w, err = g.Clone()
+		provider, err := ScanProvider(log, p)
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "cannot scan provider %q", p.Name)
 		}
 
-		bf = w.Filesystem()
+		if err := renderFile(log, p.Output, provider, p.Template); err != nil {
+			return errors.Wrapf(err, "cannot render filters of provider %q", p.Name)
+		}
 
-		log.Debug("git clone complete")
-	} else {
-		log.Debug("using existing git repo", "directory", c.RepositoryDir)
+		providers = append(providers, provider)
+	}
+
+	if err := renderFile(log, cfg.Aggregate.Output, providers, cfg.Aggregate.Template); err != nil {
+		return errors.Wrap(err, "cannot render aggregate filters")
+	}
 
-		bf = filesystemfs
+	for _, o := range cfg.Outputs {
+		if err := renderFormat(log, o.Output, o.Format, providers); err != nil {
+			return errors.Wrapf(err, "cannot render %s filters", o.Format)
+		}
 	}
 
-	log.Debug("examining CRD files", "directory", c.CrossplanePackageCRDDir)
+	return nil
+}
 
-	filter, err := ExamineFieldFromCRDVersions(bf, g.CRDDir)
+// ScanProvider opens the source of a provider and examines its CRDs.
+func ScanProvider(log logging.Logger, p ProviderConfig) (render.Provider, error) {
+	src, err := NewSource(log, p)
 	if err != nil {
-		return err
+		return render.Provider{}, err
 	}
 
-	var out *os.File
-	if c.OutputFile == "" {
-		out = os.Stdout
+	bf, root, err := src.Open()
+	if err != nil {
+		return render.Provider{}, err
+	}
+
+	log.Debug("examining CRD files", "source", p.GetSourceType(), "directory", root)
+
+	var (
+		filter render.FilterList
+		rev    Revision
+		ref    string
+	)
+
+	if g, ok := src.(Generator); ok {
+		filter, rev, err = g.Examine(bf, p.TagFieldPaths, p.Name, p.Versions)
+		ref = p.Ref
 	} else {
-		out, err = os.Create(c.OutputFile)
-		if err != nil {
-			return err
+		filter, err = examine(log, bf, root, p.TagFieldPaths, p.ContinueOnError)
+	}
+
+	if err != nil {
+		return render.Provider{}, err
+	}
+
+	log.Info("scanned provider CRDs", "provider", p.Name, "version", rev.Version, "commit", rev.Commit)
+
+	return render.Provider{
+		Name:          p.Name,
+		Prefix:        p.Prefix,
+		GroupSuffix:   p.GroupSuffix,
+		TagFieldPaths: p.TagFieldPaths,
+		Filters:       filter,
+		Source:        p.Source,
+		Ref:           ref,
+		Version:       rev.Version,
+		Commit:        rev.Commit,
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// examine examines the CRDs of a directory, and logs the summary of the
+// scan.
+func examine(log logging.Logger, bf billy.Filesystem, root string, tagFieldPaths []string, continueOnError bool) (render.FilterList, error) {
+	filter, summary, err := ExamineCRDs(bf, root, tagFieldPaths, continueOnError)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range summary.Duplicates {
+		if d.Conflicting {
+			log.Info("kind is defined by CRDs with conflicting verdicts, keeping the first", "kind", d.GroupKind, "files", strings.Join(d.Files, ", "))
+			continue
 		}
 
-		defer func() { _ = out.Close() }()
+		log.Debug("kind is defined by several CRDs", "kind", d.GroupKind, "files", strings.Join(d.Files, ", "))
+	}
+
+	for _, e := range summary.Errors {
+		log.Info("skipped CRD file", "file", e.Path, "error", e.Err)
+	}
+
+	if len(summary.Errors) > 0 || summary.Conflicts() > 0 {
+		log.Info("examined CRD files with errors", "directory", root, "entries", len(filter), "errors", len(summary.Errors), "conflicts", summary.Conflicts())
+	}
+
+	return filter, nil
+}
+
+// renderFile renders a template of Go source to a file, or to stdout if
+// path is empty.
+func renderFile(log logging.Logger, path string, data any, templateFile string) error {
+	return writeFile(log, path, func(w io.Writer) error {
+		return render.RenderGo(w, data, templateFile)
+	})
+}
+
+// renderFormat renders the filters of providers in a built-in format to a
+// file, or to stdout if path is empty.
+func renderFormat(log logging.Logger, path, format string, providers []render.Provider) error {
+	return writeFile(log, path, func(w io.Writer) error {
+		return render.RenderFormat(w, format, providers)
+	})
+}
+
+// writeFile calls write with a buffer, and writes the buffer to a file, or
+// to stdout if path is empty. The file is replaced atomically through a
+// temporary file, so it is left unchanged if write fails.
+func writeFile(log logging.Logger, path string, write func(w io.Writer) error) error {
+	buf := &bytes.Buffer{}
+	if err := write(buf); err != nil {
+		return err
+	}
+
+	if path == "" {
+		log.Debug("rendering", "location", os.Stdout.Name())
+
+		_, err := buf.WriteTo(os.Stdout)
+
+		return err
+	}
+
+	log.Debug("rendering", "location", path)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "cannot create temporary file for %q", path)
+	}
+
+	// Removing the temporary file fails once it is renamed
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := buf.WriteTo(tmp); err != nil {
+		_ = tmp.Close()
+		return errors.Wrapf(err, "cannot write %q", tmp.Name())
+	}
+
+	if err := tmp.Chmod(0o644); err != nil { //nolint:gosec // Generated files are readable like other source files.
+		_ = tmp.Close()
+		return errors.Wrapf(err, "cannot set the mode of %q", tmp.Name())
 	}
 
-	log.Debug("rendering template", "location", out.Name())
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "cannot close %q", tmp.Name())
+	}
 
-	return render.Render(out, filter, c.TemplateFile)
+	return errors.Wrapf(os.Rename(tmp.Name(), path), "cannot replace %q", path)
 }
 
 func main() {
@@ -127,32 +310,126 @@ func main() {
 	ctx.FatalIfErrorf(ctx.Run())
 }
 
-// Clone performs a sparse checkout of a git repository.
-func (g Generator) Clone() (*git.Worktree, error) {
+// Clone clones a git repository without checking out its files.
+func (g Generator) Clone() error {
 	g.Logger.Info("cloning repo", "url", g.RepoURL)
 
-	r, err := git.Clone(g.Storage, g.Worktree, &git.CloneOptions{
+	_, err := git.Clone(g.Storage, g.Worktree, &git.CloneOptions{
 		NoCheckout: true,
+		Tags:       git.AllTags,
 		URL:        g.RepoURL,
 	})
+
+	return errors.Wrapf(err, "unable to clone %q", g.RepoURL)
+}
+
+// Examine returns the filters of the CRDs in a filesystem, and the revision
+// they were read from. If versions are set, the CRDs of each version are
+// checked out and scanned, and the range of versions in which each kind
+// supports tags is recorded.
+func (g Generator) Examine(bf billy.Filesystem, tagFieldPaths []string, provider string, versions []string) (render.FilterList, Revision, error) {
+	if len(versions) == 0 {
+		rev, err := g.Revision(g.Reference)
+		if err != nil {
+			return nil, Revision{}, err
+		}
+
+		filter, err := examine(g.Logger, bf, g.CRDDir, tagFieldPaths, g.ContinueOnError)
+
+		return filter, rev, err
+	}
+
+	scans := make([]ProviderScan, 0, len(versions))
+
+	var rev Revision
+
+	for _, v := range versions {
+		g.Logger.Debug("checking out provider version", "version", v)
+
+		var err error
+
+		rev, err = g.Checkout(plumbing.NewTagReferenceName(v).String())
+		if err != nil {
+			return nil, Revision{}, errors.Wrapf(err, "cannot check out provider version %q", v)
+		}
+
+		filter, err := examine(g.Logger, g.Worktree, g.CRDDir, tagFieldPaths, g.ContinueOnError)
+		if err != nil {
+			return nil, Revision{}, errors.Wrapf(err, "cannot examine CRDs of provider version %q", v)
+		}
+
+		scans = append(scans, ProviderScan{Version: v, Filters: filter})
+	}
+
+	// The filters are those of the latest version
+	return MergeProviderScans(provider, scans), rev, nil
+}
+
+// Checkout checks out the CRD paths of a reference of the cloned repository.
+// The repository is fetched first if the reference is a branch, or is not in
+// the repository.
+func (g Generator) Checkout(reference string) (Revision, error) {
+	return g.checkout(reference, true)
+}
+
+func (g Generator) checkout(reference string, fetch bool) (Revision, error) {
+	r, err := git.Open(g.Storage, g.Worktree)
 	if err != nil {
-		return nil, err
+		return Revision{}, errors.Wrapf(err, "unable to open repository")
+	}
+
+	h, mutable, err := resolveReference(r, reference)
+	if fetch && (err != nil || mutable) {
+		g.Logger.Debug("fetching repo", "url", g.RepoURL, "reference", reference)
+
+		ferr := r.Fetch(&git.FetchOptions{Tags: git.AllTags, Force: true})
+		if ferr != nil && !errors.Is(ferr, git.NoErrAlreadyUpToDate) {
+			g.Logger.Info("cannot fetch repo, using the cached repository", "url", g.RepoURL, "error", ferr)
+		}
+
+		h, _, err = resolveReference(r, reference)
+	}
+
+	if err != nil {
+		return Revision{}, err
 	}
 
 	wt, err := r.Worktree()
 	if err != nil {
-		g.Logger.Info("error: unable to get worktree", "error", err)
-		return nil, errors.Wrapf(err, "unable to get worktree")
+		return Revision{}, errors.Wrapf(err, "unable to get worktree")
+	}
+
+	// Files of the previous checkout that are not in the commit are not
+	// removed by a checkout
+	for _, p := range g.Paths {
+		if err := util.RemoveAll(g.Worktree, p); err != nil {
+			return Revision{}, errors.Wrapf(err, "cannot clean %q", p)
+		}
 	}
 
 	err = wt.Checkout(&git.CheckoutOptions{
-		Branch:                    plumbing.ReferenceName(g.Reference),
+		Hash:                      h,
 		SparseCheckoutDirectories: g.Paths,
+		Force:                     true,
 	})
 	if err != nil {
-		g.Logger.Info("error: unable to checkout paths", "error", err)
-		return nil, errors.Wrapf(err, "unable to checkout paths")
+		return Revision{}, errors.Wrapf(err, "unable to checkout paths")
+	}
+
+	return newRevision(r, reference, h)
+}
+
+// Revision returns the revision of a reference of the cloned repository.
+func (g Generator) Revision(reference string) (Revision, error) {
+	r, err := git.Open(g.Storage, g.Worktree)
+	if err != nil {
+		return Revision{}, errors.Wrapf(err, "unable to open repository")
+	}
+
+	h, _, err := resolveReference(r, reference)
+	if err != nil {
+		return Revision{}, err
 	}
 
-	return wt, nil
+	return newRevision(r, reference, h)
 }
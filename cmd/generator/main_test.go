@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+func TestWriteFile(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		write  func(w io.Writer) error
+		want   string
+		err    bool
+	}{
+		"Replace": {
+			reason: "The file is replaced with what was written",
+			write: func(w io.Writer) error {
+				_, err := io.WriteString(w, "updated\n")
+				return err
+			},
+			want: "updated\n",
+		},
+		"KeepOnError": {
+			reason: "The file is unchanged if writing fails part way",
+			write: func(w io.Writer) error {
+				_, _ = io.WriteString(w, "partial")
+				return errors.New("boom")
+			},
+			want: "existing\n",
+			err:  true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "zz_provider-upjet-aws.go")
+
+			if err := os.WriteFile(path, []byte("existing\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			err := writeFile(logging.NewNopLogger(), path, tc.write)
+			if (err != nil) != tc.err {
+				t.Errorf("%s\nwriteFile(...): want error %t, got %v", tc.reason, tc.err, err)
+			}
+
+			bs, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.want, string(bs)); diff != "" {
+				t.Errorf("%s\nwriteFile(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(entries) != 1 {
+				t.Errorf("%s\nwriteFile(...): want no temporary files left, got %d entries", tc.reason, len(entries))
+			}
+		})
+	}
+}
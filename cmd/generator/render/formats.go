@@ -0,0 +1,157 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+)
+
+// Formats of rendered filters. Go source is rendered from a template, and
+// the other formats by built-in renderers.
+const (
+	FormatGo       = "go"
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatMarkdown = "markdown"
+)
+
+// namespacedGroupMarker is the label of the Namespace-scoped API groups of
+// upjet providers, like ec2.aws.m.upbound.io.
+const namespacedGroupMarker = ".m."
+
+// Coverage counts the kinds of a provider or an API group that support tags.
+type Coverage struct {
+	Name     string `json:"name"`
+	Kinds    int    `json:"kinds"`
+	Taggable int    `json:"taggable"`
+}
+
+// ProviderCoverage counts the kinds of a provider and of each of its API
+// groups that support tags.
+type ProviderCoverage struct {
+	Coverage
+
+	Groups []Coverage `json:"groups"`
+}
+
+// Coverage counts the kinds of the filters that support tags. A kind of a
+// Namespace-scoped API group is counted as the same kind of its
+// Cluster-scoped API group, and supports tags if either entry does.
+func (l FilterList) Coverage(name string) ProviderCoverage {
+	kinds := make(map[string]map[string]bool)
+
+	for _, f := range l {
+		// Entries of API versions are counted with their kind
+		if f.APIVersion != "" {
+			continue
+		}
+
+		group, kind, _ := strings.Cut(f.GroupKind, "/")
+		group = strings.Replace(group, namespacedGroupMarker, ".", 1)
+
+		if kinds[group] == nil {
+			kinds[group] = make(map[string]bool)
+		}
+
+		kinds[group][kind] = kinds[group][kind] || f.Enabled
+	}
+
+	c := ProviderCoverage{Coverage: Coverage{Name: name}, Groups: make([]Coverage, 0, len(kinds))}
+
+	for group, enabled := range kinds {
+		g := Coverage{Name: group, Kinds: len(enabled)}
+
+		for _, e := range enabled {
+			if e {
+				g.Taggable++
+			}
+		}
+
+		c.Kinds += g.Kinds
+		c.Taggable += g.Taggable
+		c.Groups = append(c.Groups, g)
+	}
+
+	sort.Slice(c.Groups, func(i, j int) bool {
+		return c.Groups[i].Name < c.Groups[j].Name
+	})
+
+	return c
+}
+
+// RenderFormat renders the filters of providers in a built-in format.
+func RenderFormat(writer io.Writer, format string, providers []Provider) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(writer)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(providers)
+	case FormatYAML:
+		bs, err := yaml.Marshal(providers)
+		if err != nil {
+			return err
+		}
+
+		_, err = writer.Write(bs)
+
+		return err
+	case FormatMarkdown:
+		return renderMarkdown(writer, providers)
+	}
+
+	return errors.Errorf("unknown format %q", format)
+}
+
+// renderMarkdown renders a table of the kinds of each provider that support
+// tags, followed by a table per provider of its API groups.
+func renderMarkdown(writer io.Writer, providers []Provider) error {
+	b := &strings.Builder{}
+
+	b.WriteString("<!-- Code generated by cmd/generator. DO NOT EDIT. -->\n\n")
+	b.WriteString("# Tag Support Coverage\n\n")
+	b.WriteString("Counts are per resource kind. The kinds of Namespace-scoped API groups, like\n")
+	b.WriteString("`ec2.aws.m.upbound.io`, are counted with the kinds of their Cluster-scoped API group.\n\n")
+	b.WriteString("| Provider | Version | Kinds | Support tags | Do not support tags |\n")
+	b.WriteString("|----------|---------|------:|-------------:|--------------------:|\n")
+
+	coverage := make([]ProviderCoverage, len(providers))
+
+	for i, p := range providers {
+		coverage[i] = p.Filters.Coverage(p.Name)
+
+		version := p.Version
+		if version == "" {
+			version = "unknown"
+		}
+
+		c := coverage[i]
+		fmt.Fprintf(b, "| %s | %s | %d | %d | %d |\n", p.Name, version, c.Kinds, c.Taggable, c.Kinds-c.Taggable)
+	}
+
+	for _, c := range coverage {
+		fmt.Fprintf(b, "\n## %s\n\n", c.Name)
+
+		if len(c.Groups) == 0 {
+			b.WriteString("No kinds were found.\n")
+			continue
+		}
+
+		b.WriteString("| API group | Kinds | Support tags | Do not support tags |\n")
+		b.WriteString("|-----------|------:|-------------:|--------------------:|\n")
+
+		for _, g := range c.Groups {
+			fmt.Fprintf(b, "| `%s` | %d | %d | %d |\n", g.Name, g.Kinds, g.Taggable, g.Kinds-g.Taggable)
+		}
+	}
+
+	_, err := io.WriteString(writer, b.String())
+
+	return err
+}
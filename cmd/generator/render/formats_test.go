@@ -0,0 +1,170 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilterListCoverage(t *testing.T) {
+	filters := FilterList{
+		{GroupKind: "ec2.aws.m.upbound.io/Instance", Enabled: true},
+		{GroupKind: "ec2.aws.upbound.io/Instance", Enabled: true},
+		{GroupKind: "ec2.aws.m.upbound.io/Route", Enabled: false},
+		{GroupKind: "ec2.aws.upbound.io/Route", Enabled: false},
+		{GroupKind: "ec2.aws.upbound.io/Route", APIVersion: "v1beta1", Enabled: true},
+		{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+		{GroupKind: "aws.m.upbound.io/ClusterProviderConfig", Enabled: false},
+	}
+
+	want := ProviderCoverage{
+		Coverage: Coverage{Name: "provider-upjet-aws", Kinds: 4, Taggable: 2},
+		Groups: []Coverage{
+			{Name: "aws.upbound.io", Kinds: 1, Taggable: 0},
+			{Name: "ec2.aws.upbound.io", Kinds: 2, Taggable: 1},
+			{Name: "s3.aws.upbound.io", Kinds: 1, Taggable: 1},
+		},
+	}
+
+	if diff := cmp.Diff(want, filters.Coverage("provider-upjet-aws")); diff != "" {
+		t.Errorf("Coverage(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestFilterKey(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		key    string
+		want   Filter
+	}{
+		"GroupKind": {
+			reason: "A group/Kind key is the filter of a kind",
+			key:    "ec2.aws.upbound.io/Instance",
+			want:   Filter{GroupKind: "ec2.aws.upbound.io/Instance"},
+		},
+		"GroupVersionKind": {
+			reason: "A group/version/Kind key is the filter of an API version of a kind",
+			key:    "ec2.aws.upbound.io/v1beta1/Instance",
+			want:   Filter{GroupKind: "ec2.aws.upbound.io/Instance", APIVersion: "v1beta1"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ParseKey(tc.key)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nParseKey(%q): -want, +got:\n%s", tc.reason, tc.key, diff)
+			}
+
+			if got.Key() != tc.key {
+				t.Errorf("%s\nKey(): want %q, got %q", tc.reason, tc.key, got.Key())
+			}
+		})
+	}
+}
+
+func TestRenderFormat(t *testing.T) {
+	providers := []Provider{
+		{
+			Name:          "provider-upjet-aws",
+			Prefix:        "AWS",
+			TagFieldPaths: []string{"spec.forProvider.tags"},
+			Source:        "https://github.com/crossplane-contrib/provider-upjet-aws.git",
+			Version:       "v2.7.0",
+			Filters: FilterList{
+				{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+				{GroupKind: "s3.aws.upbound.io/BucketPolicy", Enabled: false},
+			},
+		},
+	}
+
+	type want struct {
+		out string
+		err bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		format string
+		want   want
+	}{
+		"JSON": {
+			reason: "Providers are rendered as a JSON list",
+			format: FormatJSON,
+			want: want{out: `[
+  {
+    "name": "provider-upjet-aws",
+    "prefix": "AWS",
+    "tagFieldPaths": [
+      "spec.forProvider.tags"
+    ],
+    "filters": [
+      {
+        "groupKind": "s3.aws.upbound.io/Bucket",
+        "enabled": true
+      },
+      {
+        "groupKind": "s3.aws.upbound.io/BucketPolicy",
+        "enabled": false
+      }
+    ],
+    "source": "https://github.com/crossplane-contrib/provider-upjet-aws.git",
+    "version": "v2.7.0"
+  }
+]
+`},
+		},
+		"YAML": {
+			reason: "Providers are rendered as a YAML list",
+			format: FormatYAML,
+			want: want{out: `- filters:
+  - enabled: true
+    groupKind: s3.aws.upbound.io/Bucket
+  - enabled: false
+    groupKind: s3.aws.upbound.io/BucketPolicy
+  name: provider-upjet-aws
+  prefix: AWS
+  source: https://github.com/crossplane-contrib/provider-upjet-aws.git
+  tagFieldPaths:
+  - spec.forProvider.tags
+  version: v2.7.0
+`},
+		},
+		"Markdown": {
+			reason: "The kinds of each provider and API group that support tags are counted",
+			format: FormatMarkdown,
+			want: want{out: "<!-- Code generated by cmd/generator. DO NOT EDIT. -->\n\n" +
+				"# Tag Support Coverage\n\n" +
+				"Counts are per resource kind. The kinds of Namespace-scoped API groups, like\n" +
+				"`ec2.aws.m.upbound.io`, are counted with the kinds of their Cluster-scoped API group.\n\n" +
+				"| Provider | Version | Kinds | Support tags | Do not support tags |\n" +
+				"|----------|---------|------:|-------------:|--------------------:|\n" +
+				"| provider-upjet-aws | v2.7.0 | 2 | 1 | 1 |\n\n" +
+				"## provider-upjet-aws\n\n" +
+				"| API group | Kinds | Support tags | Do not support tags |\n" +
+				"|-----------|------:|-------------:|--------------------:|\n" +
+				"| `s3.aws.upbound.io` | 2 | 1 | 1 |\n"},
+		},
+		"UnknownFormat": {
+			reason: "Go source is rendered from a template",
+			format: FormatGo,
+			want:   want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			err := RenderFormat(buf, tc.format, providers)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("%s\nRenderFormat(...): want error %t, got %v", tc.reason, tc.want.err, err)
+			}
+
+			if diff := cmp.Diff(tc.want.out, buf.String()); diff != "" {
+				t.Errorf("%s\nRenderFormat(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
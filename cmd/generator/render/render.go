@@ -4,26 +4,138 @@ package render
 import (
 	"io"
 	"path/filepath"
+	"strings"
 	"text/template"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters/crd"
+)
+
+// Tag shapes detected in CRD schemas.
+const (
+	TagShapeMap          = "Map"
+	TagShapeKeyValueList = "KeyValueList"
 )
 
+// TagPath is where a kind keeps tags when they are not a map at
+// spec.forProvider.tags.
+type TagPath struct {
+	Desired  string `json:"desired"`
+	Observed string `json:"observed"`
+	Shape    string `json:"shape,omitempty"`
+}
+
+// TagField is a field of the spec of a kind that holds tags.
+type TagField struct {
+	Path  string `json:"path"`
+	Shape string `json:"shape"`
+}
+
 // Filter contains a Kubernetes GroupKind and whether it supports tags.
 type Filter struct {
-	GroupKind string
-	Enabled   bool
+	GroupKind string `json:"groupKind"`
+	// APIVersion is set for a served API version of the kind whose tag
+	// support differs from the storage version of its CRD.
+	APIVersion string `json:"apiVersion,omitempty"`
+	Enabled    bool   `json:"enabled"`
+	// TagPaths are set for kinds that do not keep tags in a map at
+	// spec.forProvider.tags.
+	TagPaths []TagPath `json:"tagPaths,omitempty"`
+	// TagFields are all fields of the spec of the kind that hold tags or
+	// labels.
+	TagFields []TagField `json:"tagFields,omitempty"`
+	// Provider is the name of the provider of the kind.
+	Provider string `json:"provider,omitempty"`
+	// MinVersion and MaxVersion are the range of scanned provider versions
+	// in which the kind supports tags. They are only set for kinds whose
+	// tag support changed between the scanned versions.
+	MinVersion string `json:"minVersion,omitempty"`
+	MaxVersion string `json:"maxVersion,omitempty"`
+}
+
+// Key returns the ResourceFilter key of the filter, which is its
+// group/version/Kind if it has an APIVersion, or else its group/Kind.
+func (f Filter) Key() string {
+	if f.APIVersion == "" {
+		return f.GroupKind
+	}
+
+	group, kind, _ := strings.Cut(f.GroupKind, "/")
+
+	return group + "/" + f.APIVersion + "/" + kind
+}
+
+// ParseKey returns the Filter of a ResourceFilter key, which is a
+// group/Kind or a group/version/Kind.
+func ParseKey(key string) Filter {
+	group, rest, _ := strings.Cut(key, "/")
+	if version, kind, ok := strings.Cut(rest, "/"); ok {
+		return Filter{GroupKind: group + "/" + kind, APIVersion: version}
+	}
+
+	return Filter{GroupKind: key}
 }
 
 // FilterList is a list of Filters.
 type FilterList []Filter
 
-// Render renders resource.
-func Render(writer io.Writer, resources []Filter, templateFile string) error {
+// Provider contains the Filters of a provider.
+type Provider struct {
+	// Name of the provider, like provider-upjet-aws.
+	Name string `json:"name"`
+	// Prefix of the generated function names, like AWS.
+	Prefix string `json:"prefix"`
+	// GroupSuffix of the API groups of the provider family, like aws.upbound.io.
+	GroupSuffix string `json:"groupSuffix,omitempty"`
+	// TagFieldPaths are the field paths of spec.forProvider that were
+	// probed for tags, like spec.forProvider.labels.
+	TagFieldPaths []string `json:"tagFieldPaths"`
+	// Filters of the kinds of the provider.
+	Filters FilterList `json:"filters"`
+	// Source is the repository, directory or package the CRDs were read from.
+	Source string `json:"source"`
+	// Ref is the Git reference that was scanned.
+	Ref string `json:"ref,omitempty"`
+	// Version is the release tag of the scanned commit, if any.
+	Version string `json:"version,omitempty"`
+	// Commit is the scanned commit.
+	Commit string `json:"commit,omitempty"`
+	// GeneratedAt is when the filters were generated, in RFC 3339 format.
+	GeneratedAt string `json:"generatedAt,omitempty"`
+}
+
+// TagPath returns where kinds of the provider keep tags in a map: its first
+// tag field path, or spec.forProvider.tags.
+func (p Provider) TagPath() TagPath {
+	fields := []string{crd.FieldSpec, crd.FieldForProvider, crd.FieldTags}
+
+	if len(p.TagFieldPaths) > 0 {
+		if f, err := crd.ParseTagFieldPath(p.TagFieldPaths[0]); err == nil {
+			fields = f
+		}
+	}
+
+	return TagPath{
+		Desired:  strings.Join(fields, "."),
+		Observed: crd.FieldStatus + "." + crd.FieldAtProvider + "." + strings.Join(fields[2:], "."),
+		Shape:    TagShapeMap,
+	}
+}
+
+// TagField returns the name of the field of the TagPath, like tags or
+// labels.
+func (p Provider) TagField() string {
+	desired := p.TagPath().Desired
+	return desired[strings.LastIndex(desired, ".")+1:]
+}
+
+// Render renders a template with data, like a Provider or a list of Providers.
+func Render(writer io.Writer, data any, templateFile string) error {
 	tmpl, err := template.New(filepath.Base(templateFile)).ParseFiles(templateFile)
 	if err != nil {
 		return err
 	}
 
-	err = tmpl.Execute(writer, resources)
+	err = tmpl.Execute(writer, data)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,134 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+)
+
+// contextLines is the number of lines quoted before and after the line of
+// an error in rendered source.
+const contextLines = 2
+
+// RenderGo renders a template of Go source with data, and writes the source
+// formatted with gofmt. Nothing is written if the rendered source is not
+// valid Go, or has a composite literal with duplicate keys.
+func RenderGo(writer io.Writer, data any, templateFile string) error {
+	buf := &bytes.Buffer{}
+	if err := Render(buf, data, templateFile); err != nil {
+		return err
+	}
+
+	src, err := FormatSource(templateFile, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(src)
+
+	return err
+}
+
+// FormatSource checks that Go source rendered from a template parses and has
+// no composite literals with duplicate keys, like a kind listed twice in a
+// ResourceFilter, and formats it with gofmt. Errors quote the lines of the
+// rendered source around the error.
+func FormatSource(templateFile string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, templateFile, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		var list scanner.ErrorList
+		if errors.As(err, &list) && len(list) > 0 {
+			return nil, sourceError(templateFile, src, list[0].Pos.Line, list[0].Msg)
+		}
+
+		return nil, errors.Wrapf(err, "cannot parse source rendered from template %q", templateFile)
+	}
+
+	if err := checkDuplicateKeys(fset, f, templateFile, src); err != nil {
+		return nil, err
+	}
+
+	out, err := format.Source(src)
+
+	return out, errors.Wrapf(err, "cannot format source rendered from template %q", templateFile)
+}
+
+// checkDuplicateKeys returns an error for the first key that is repeated in
+// a composite literal.
+func checkDuplicateKeys(fset *token.FileSet, f *ast.File, templateFile string, src []byte) error {
+	var err error
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || err != nil {
+			return err == nil
+		}
+
+		seen := make(map[string]int, len(lit.Elts))
+
+		for _, e := range lit.Elts {
+			kv, ok := e.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+
+			key := literalKey(kv.Key)
+			line := fset.Position(kv.Pos()).Line
+
+			if first, ok := seen[key]; ok {
+				err = sourceError(templateFile, src, line, fmt.Sprintf("duplicate key %s, first set on line %d", key, first))
+				return false
+			}
+
+			seen[key] = line
+		}
+
+		return true
+	})
+
+	return err
+}
+
+// literalKey returns a key of a composite literal, with the quotes of
+// strings normalized.
+func literalKey(key ast.Expr) string {
+	if b, ok := key.(*ast.BasicLit); ok && b.Kind == token.STRING {
+		if s, err := strconv.Unquote(b.Value); err == nil {
+			return strconv.Quote(s)
+		}
+	}
+
+	return types.ExprString(key)
+}
+
+// sourceError returns an error at a line of source rendered from a
+// template, which quotes the lines around it.
+func sourceError(templateFile string, src []byte, line int, msg string) error {
+	lines := strings.Split(string(src), "\n")
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "invalid source rendered from template %q, line %d: %s", templateFile, line, msg)
+
+	for i := max(line-contextLines, 1); i <= min(line+contextLines, len(lines)); i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+
+		fmt.Fprintf(b, "\n%s %4d | %s", marker, i, lines[i-1])
+	}
+
+	return errors.New(b.String())
+}
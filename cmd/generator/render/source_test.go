@@ -0,0 +1,147 @@
+package render
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormatSource(t *testing.T) {
+	type want struct {
+		out string
+		err []string
+	}
+
+	cases := map[string]struct {
+		reason string
+		src    string
+		want   want
+	}{
+		"Valid": {
+			reason: "Valid source is formatted with gofmt",
+			src: `package filters
+
+func NewResourceFilter() ResourceFilter {
+    return ResourceFilter{
+        "s3.aws.upbound.io/Bucket": true,
+        "s3.aws.upbound.io/BucketPolicy": false,
+    }
+}
+`,
+			want: want{out: `package filters
+
+func NewResourceFilter() ResourceFilter {
+	return ResourceFilter{
+		"s3.aws.upbound.io/Bucket":       true,
+		"s3.aws.upbound.io/BucketPolicy": false,
+	}
+}
+`},
+		},
+		"SyntaxError": {
+			reason: "A syntax error quotes the lines around it",
+			src: `package filters
+
+func NewResourceFilter() ResourceFilter {
+    return ResourceFilter{
+        "s3.aws.upbound.io/Bucket": true
+        "s3.aws.upbound.io/BucketPolicy": false,
+    }
+}
+`,
+			want: want{err: []string{
+				`template "provider.tmpl", line 5:`,
+				`>    5 |         "s3.aws.upbound.io/Bucket": true`,
+				`     7 |     }`,
+			}},
+		},
+		"DuplicateKey": {
+			reason: "A key repeated in a literal is an error, even if it is quoted differently",
+			src: `package filters
+
+func NewResourceFilter() ResourceFilter {
+    return ResourceFilter{
+        "s3.aws.upbound.io/Bucket": true,
+        ` + "`s3.aws.upbound.io/Bucket`" + `: false,
+    }
+}
+`,
+			want: want{err: []string{
+				`line 6: duplicate key "s3.aws.upbound.io/Bucket", first set on line 5`,
+			}},
+		},
+		"SameKeyInOtherLiterals": {
+			reason: "The same key may be set in different literals",
+			src: `package filters
+
+var a, b = map[string]bool{"x": true}, map[string]bool{"x": false}
+`,
+			want: want{out: `package filters
+
+var a, b = map[string]bool{"x": true}, map[string]bool{"x": false}
+`},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := FormatSource("provider.tmpl", []byte(tc.src))
+
+			if len(tc.want.err) > 0 {
+				if err == nil {
+					t.Fatalf("%s\nFormatSource(...): want error, got nil", tc.reason)
+				}
+
+				for _, s := range tc.want.err {
+					if !strings.Contains(err.Error(), s) {
+						t.Errorf("%s\nFormatSource(...): want error containing %q, got:\n%s", tc.reason, s, err)
+					}
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("%s\nFormatSource(...): unexpected error: %v", tc.reason, err)
+			}
+
+			if diff := cmp.Diff(tc.want.out, string(out)); diff != "" {
+				t.Errorf("%s\nFormatSource(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderGo(t *testing.T) {
+	tmpl := filepath.Join(t.TempDir(), "provider.tmpl")
+	if err := os.WriteFile(tmpl, []byte("package filters\n\nvar f = ResourceFilter{\n{{- range .Filters }}\n\"{{.GroupKind}}\": {{.Enabled}},\n{{- end }}\n}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	p := Provider{Filters: FilterList{{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true}}}
+
+	if err := RenderGo(buf, p, tmpl); err != nil {
+		t.Fatalf("RenderGo(...): unexpected error: %v", err)
+	}
+
+	want := "package filters\n\nvar f = ResourceFilter{\n\t\"s3.aws.upbound.io/Bucket\": true,\n}\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("RenderGo(...): -want, +got:\n%s", diff)
+	}
+
+	buf.Reset()
+	p.Filters = append(p.Filters, p.Filters[0])
+
+	if err := RenderGo(buf, p, tmpl); err == nil {
+		t.Errorf("RenderGo(...): want error for duplicate keys, got nil")
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("RenderGo(...): want nothing written on error, got %q", buf.String())
+	}
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-billy/v6"
+	"github.com/go-git/go-billy/v6/memfs"
+	"github.com/go-git/go-billy/v6/osfs"
+	"github.com/go-git/go-billy/v6/util"
+	git "github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/cache"
+	"github.com/go-git/go-git/v6/storage/filesystem"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+// SourceType is where the CRDs of a provider are read from.
+type SourceType string
+
+const (
+	// SourceGit clones the provider's Git repository.
+	SourceGit SourceType = "git"
+	// SourceDir reads CRDs from a local directory.
+	SourceDir SourceType = "dir"
+	// SourceTar reads CRDs from a tarball, which may be compressed with gzip.
+	SourceTar SourceType = "tar"
+	// SourceXpkg reads CRDs from the package.yaml of a Crossplane package
+	// file (.xpkg), which is an OCI image tarball.
+	SourceXpkg SourceType = "xpkg"
+)
+
+// xpkgPackageFile is the file of a Crossplane package image that contains
+// the package metadata and its CRDs.
+const xpkgPackageFile = "package.yaml"
+
+// gzipMagic are the first bytes of a gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Source provides the CRD files of a provider.
+type Source interface {
+	// Open returns a filesystem and the directory of the CRDs in it.
+	Open() (billy.Filesystem, string, error)
+}
+
+// NewSource returns the Source of a provider.
+func NewSource(log logging.Logger, p ProviderConfig) (Source, error) {
+	switch p.GetSourceType() {
+	case SourceGit:
+		filesystemfs := osfs.New(p.RepositoryDir)
+
+		return Generator{
+			Cloner: Cloner{
+				Paths:     []string{p.CRDDir},
+				Reference: p.Ref,
+				RepoURL:   p.Source,
+				Storage:   filesystem.NewStorage(filesystemfs, cache.NewObjectLRU(cache.DefaultMaxSize)),
+				Worktree:  filesystemfs,
+			},
+			CRDDir:          p.CRDDir,
+			Logger:          log,
+			RepoDirectory:   p.RepositoryDir,
+			ContinueOnError: p.ContinueOnError,
+		}, nil
+	case SourceDir:
+		return DirSource{Path: p.Source, CRDDir: p.CRDDir}, nil
+	case SourceTar:
+		return TarSource{Path: p.Source, CRDDir: p.CRDDir}, nil
+	case SourceXpkg:
+		return XpkgSource{Path: p.Source, ContinueOnError: p.ContinueOnError}, nil
+	}
+
+	return nil, errors.Errorf("unknown source type %q", p.SourceType)
+}
+
+// Open clones the repository, unless it exists on the filesystem, and checks
+// out the reference. An existing repository is fetched if the reference is a
+// branch, or is not in the repository.
+func (g Generator) Open() (billy.Filesystem, string, error) {
+	fetch := true
+
+	_, err := os.Stat(g.RepoDirectory)
+	if os.IsNotExist(err) {
+		g.Logger.Debug("repo does not exist on the filesystem, cloning", "directory", g.RepoDirectory)
+
+		if err := g.Clone(); err != nil {
+			return nil, "", err
+		}
+
+		g.Logger.Debug("git clone complete")
+
+		fetch = false
+	} else {
+		g.Logger.Debug("using existing git repo", "directory", g.RepoDirectory)
+	}
+
+	rev, err := g.checkout(g.Reference, fetch)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot check out %q", g.Reference)
+	}
+
+	g.Logger.Debug("checked out repo", "reference", g.Reference, "commit", rev.Commit, "version", rev.Version)
+
+	return g.Worktree, g.CRDDir, nil
+}
+
+// Revision is a resolved Git reference.
+type Revision struct {
+	// Commit is the hash of the commit.
+	Commit string
+	// Version is the release tag of the commit, if any.
+	Version string
+}
+
+// commitHash matches full and abbreviated commit hashes.
+var commitHash = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+// resolveReference returns the commit of a reference, which is a tag, a
+// branch of origin, a full reference name like refs/remotes/origin/main, or a
+// commit hash. mutable is true for references other than tags and commit
+// hashes, which may be stale in a cached repository.
+func resolveReference(r *git.Repository, reference string) (plumbing.Hash, bool, error) {
+	names := []plumbing.ReferenceName{plumbing.ReferenceName(reference)}
+	if !strings.HasPrefix(reference, "refs/") {
+		names = []plumbing.ReferenceName{
+			plumbing.NewTagReferenceName(reference),
+			plumbing.NewRemoteReferenceName(git.DefaultRemoteName, reference),
+		}
+	}
+
+	for _, name := range names {
+		ref, err := r.Reference(name, true)
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return plumbing.ZeroHash, true, errors.Wrapf(err, "cannot resolve reference %q", reference)
+		}
+
+		// Annotated tags are resolved to their commit
+		h, err := r.ResolveRevision(plumbing.Revision(ref.Hash().String()))
+		if err != nil {
+			return plumbing.ZeroHash, true, errors.Wrapf(err, "cannot resolve reference %q", reference)
+		}
+
+		return *h, !ref.Name().IsTag(), nil
+	}
+
+	if !commitHash.MatchString(reference) {
+		return plumbing.ZeroHash, true, errors.Errorf("reference %q is not a tag, branch or commit of the repository", reference)
+	}
+
+	h, err := r.ResolveRevision(plumbing.Revision(reference))
+	if err != nil {
+		return plumbing.ZeroHash, false, errors.Wrapf(err, "cannot resolve commit %q", reference)
+	}
+
+	return *h, false, nil
+}
+
+// newRevision returns the Revision of a resolved reference. The version is
+// the reference if it is a tag, or else the first tag of the commit.
+func newRevision(r *git.Repository, reference string, h plumbing.Hash) (Revision, error) {
+	rev := Revision{Commit: h.String()}
+
+	name := plumbing.ReferenceName(reference)
+	if name.IsTag() {
+		rev.Version = name.Short()
+		return rev, nil
+	}
+
+	if ref, err := r.Reference(plumbing.NewTagReferenceName(reference), false); err == nil {
+		rev.Version = ref.Name().Short()
+		return rev, nil
+	}
+
+	tags, err := r.Tags()
+	if err != nil {
+		return Revision{}, errors.Wrap(err, "cannot list tags")
+	}
+
+	versions := make([]string, 0)
+
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		th, err := r.ResolveRevision(plumbing.Revision(ref.Hash().String()))
+		if err == nil && *th == h {
+			versions = append(versions, ref.Name().Short())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Revision{}, errors.Wrap(err, "cannot list tags")
+	}
+
+	if len(versions) > 0 {
+		slices.Sort(versions)
+		rev.Version = versions[0]
+	}
+
+	return rev, nil
+}
+
+// DirSource reads CRDs from a local directory.
+type DirSource struct {
+	// Path of the directory.
+	Path string
+	// CRDDir is the directory of the CRDs, relative to Path.
+	CRDDir string
+}
+
+// Open returns the directory.
+func (s DirSource) Open() (billy.Filesystem, string, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot read CRD directory %q", s.Path)
+	}
+
+	if !info.IsDir() {
+		return nil, "", errors.Errorf("CRD source %q is not a directory", s.Path)
+	}
+
+	return osfs.New(s.Path), s.CRDDir, nil
+}
+
+// TarSource reads CRDs from a tarball.
+type TarSource struct {
+	// Path of the tarball.
+	Path string
+	// CRDDir is the directory of the CRDs in the tarball.
+	CRDDir string
+}
+
+// Open extracts the tarball to an in-memory filesystem.
+func (s TarSource) Open() (billy.Filesystem, string, error) {
+	bs, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot read CRD tarball %q", s.Path)
+	}
+
+	fs := memfs.New()
+
+	err = walkTar(bs, func(name string, data []byte) error {
+		return util.WriteFile(fs, name, data, 0o644)
+	})
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot extract CRD tarball %q", s.Path)
+	}
+
+	return fs, s.CRDDir, nil
+}
+
+// XpkgSource reads CRDs from a Crossplane package file.
+type XpkgSource struct {
+	// Path of the .xpkg file.
+	Path string
+	// ContinueOnError keeps the documents of the package.yaml that cannot
+	// be parsed, so the scan of the CRDs reports them instead of failing.
+	ContinueOnError bool
+}
+
+// Open finds the package.yaml in the layers of the package image, and
+// writes each CRD of its multi-document stream to an in-memory filesystem.
+// Documents that cannot be parsed are an error, or are written as
+// document-<index>.yaml if the source continues on errors.
+func (s XpkgSource) Open() (billy.Filesystem, string, error) {
+	bs, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot read package %q", s.Path)
+	}
+
+	pkg, err := findPackageFile(bs)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot read package %q", s.Path)
+	}
+
+	fs := memfs.New()
+
+	docs, err := splitYAMLDocuments(pkg)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot parse %s of package %q", xpkgPackageFile, s.Path)
+	}
+
+	for i, doc := range docs {
+		var u metav1.PartialObjectMetadata
+
+		err := yaml.Unmarshal(doc, &u)
+
+		switch {
+		case err != nil && !s.ContinueOnError:
+			return nil, "", errors.Wrapf(err, "cannot parse document %d of %s of package %q", i, xpkgPackageFile, s.Path)
+		case err == nil && u.Kind != "CustomResourceDefinition":
+			continue
+		}
+
+		name := u.GetName()
+
+		switch {
+		case err != nil:
+			// The scan of the CRDs collects the error of the document
+			name = "document-" + strconv.Itoa(i)
+		case name == "":
+			name = strconv.Itoa(i)
+		}
+
+		if err := util.WriteFile(fs, name+".yaml", doc, 0o644); err != nil {
+			return nil, "", errors.Wrapf(err, "cannot write CRD %q", name)
+		}
+	}
+
+	return fs, "/", nil
+}
+
+// findPackageFile returns the package.yaml of a package image tarball. The
+// file is at the root of the image, or of one of its layers, which are
+// tarballs that may be compressed with gzip.
+func findPackageFile(image []byte) ([]byte, error) {
+	var pkg []byte
+
+	err := walkTar(image, func(name string, data []byte) error {
+		if pkg != nil {
+			return nil
+		}
+
+		if path.Clean(name) == xpkgPackageFile {
+			pkg = data
+			return nil
+		}
+
+		// Files that are not layers, like the image manifest, are skipped
+		_ = walkTar(data, func(name string, data []byte) error {
+			if pkg == nil && path.Clean(name) == xpkgPackageFile {
+				pkg = data
+			}
+
+			return nil
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if pkg == nil {
+		return nil, errors.Errorf("no %s found in package", xpkgPackageFile)
+	}
+
+	return pkg, nil
+}
+
+// walkTar calls fn with the name and content of each regular file of a
+// tarball, which may be compressed with gzip.
+func walkTar(bs []byte, fn func(name string, data []byte) error) error {
+	var r io.Reader = bytes.NewReader(bs)
+
+	if bytes.HasPrefix(bs, gzipMagic) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return errors.Wrap(err, "cannot decompress tarball")
+		}
+
+		defer func() { _ = gz.Close() }()
+
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "cannot read tarball")
+		}
+
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read %q", h.Name)
+		}
+
+		if err := fn(h.Name, data); err != nil {
+			return err
+		}
+	}
+}
+
+// splitYAMLDocuments returns the non-empty documents of a multi-document
+// YAML stream.
+func splitYAMLDocuments(bs []byte) ([][]byte, error) {
+	docs := make([][]byte, 0)
+
+	err := decodeYAMLDocuments(bytes.NewReader(bs), func(_ int, doc []byte) error {
+		docs = append(docs, doc)
+		return nil
+	})
+
+	return docs, err
+}
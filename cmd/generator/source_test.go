@@ -0,0 +1,524 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crossplane-contrib/function-tag-manager/cmd/generator/render"
+	"github.com/go-git/go-billy/v6/util"
+	git "github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
+)
+
+const (
+	sourceBucketCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.s3.aws.upbound.io
+spec:
+  group: s3.aws.upbound.io
+  names:
+    kind: Bucket
+    plural: buckets
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forProvider:
+                type: object
+                properties:
+                  tags:
+                    type: object
+`
+
+	sourceCertificateCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.acmpca.aws.upbound.io
+spec:
+  group: acmpca.aws.upbound.io
+  names:
+    kind: Certificate
+    plural: certificates
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forProvider:
+                type: object
+                properties:
+                  region:
+                    type: string
+`
+
+	sourceProviderMeta = `apiVersion: meta.pkg.crossplane.io/v1
+kind: Provider
+metadata:
+  name: provider-aws-s3
+`
+)
+
+// tarball returns a tarball of files, compressed with gzip if compress is
+// set.
+func tarball(t *testing.T, files map[string][]byte, compress bool) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !compress {
+		return buf.Bytes()
+	}
+
+	gz := &bytes.Buffer{}
+	zw := gzip.NewWriter(gz)
+
+	if _, err := zw.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return gz.Bytes()
+}
+
+func TestSourceOpen(t *testing.T) {
+	crds := map[string][]byte{
+		"crds/s3.aws.upbound.io_buckets.yaml":          []byte(sourceBucketCRD),
+		"crds/acmpca.aws.upbound.io_certificates.yaml": []byte(sourceCertificateCRD),
+	}
+
+	pkg := []byte(sourceProviderMeta + "---\n" + sourceBucketCRD + "---\n" + sourceCertificateCRD)
+
+	type args struct {
+		sourceType      SourceType
+		files           map[string][]byte
+		source          []byte
+		crdDir          string
+		continueOnError bool
+	}
+
+	type want struct {
+		filters    render.FilterList
+		errorFiles []string
+		errStr     string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Directory": {
+			reason: "CRDs are read from a subdirectory of a local directory",
+			args: args{
+				sourceType: SourceDir,
+				files:      crds,
+				crdDir:     "crds",
+			},
+			want: want{filters: render.FilterList{
+				{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+				{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+			}},
+		},
+		"Tarball": {
+			reason: "CRDs are extracted from a tarball",
+			args: args{
+				sourceType: SourceTar,
+				source:     tarball(t, crds, false),
+				crdDir:     ".",
+			},
+			want: want{filters: render.FilterList{
+				{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+				{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+			}},
+		},
+		"GzipTarball": {
+			reason: "Tarballs compressed with gzip are detected",
+			args: args{
+				sourceType: SourceTar,
+				source:     tarball(t, crds, true),
+				crdDir:     "crds",
+			},
+			want: want{filters: render.FilterList{
+				{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+				{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+			}},
+		},
+		"Xpkg": {
+			reason: "CRDs are read from the package.yaml in a layer of the package image",
+			args: args{
+				sourceType: SourceXpkg,
+				source: tarball(t, map[string][]byte{
+					"manifest.json": []byte(`[{"Layers":["layer.tar.gz"]}]`),
+					"layer.tar.gz":  tarball(t, map[string][]byte{xpkgPackageFile: pkg}, true),
+				}, false),
+			},
+			want: want{filters: render.FilterList{
+				{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+				{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+			}},
+		},
+		"XpkgPackageAtRoot": {
+			reason: "The package.yaml may be at the root of the package image",
+			args: args{
+				sourceType: SourceXpkg,
+				source:     tarball(t, map[string][]byte{xpkgPackageFile: []byte(sourceProviderMeta + "---\n" + sourceBucketCRD)}, false),
+			},
+			want: want{filters: render.FilterList{
+				{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+			}},
+		},
+		"XpkgInvalidDocument": {
+			reason: "A document of the package.yaml that cannot be parsed is an error",
+			args: args{
+				sourceType: SourceXpkg,
+				source:     tarball(t, map[string][]byte{xpkgPackageFile: []byte(sourceBucketCRD + "---\nkind: [\n")}, false),
+			},
+			want: want{errStr: "cannot parse document 1 of package.yaml"},
+		},
+		"XpkgInvalidDocumentContinueOnError": {
+			reason: "A document of the package.yaml that cannot be parsed is reported by the scan when continuing on errors",
+			args: args{
+				sourceType:      SourceXpkg,
+				source:          tarball(t, map[string][]byte{xpkgPackageFile: []byte(sourceBucketCRD + "---\nkind: [\n")}, false),
+				continueOnError: true,
+			},
+			want: want{
+				filters: render.FilterList{
+					{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+				},
+				errorFiles: []string{"/document-1.yaml"},
+			},
+		},
+		"XpkgWithoutPackage": {
+			reason: "A package image without package.yaml is an error",
+			args: args{
+				sourceType: SourceXpkg,
+				source:     tarball(t, map[string][]byte{"manifest.json": []byte(`[]`)}, false),
+			},
+			want: want{errStr: "no package.yaml found in package"},
+		},
+		"MissingDirectory": {
+			reason: "A missing directory is an error",
+			args: args{
+				sourceType: SourceDir,
+				crdDir:     ".",
+			},
+			want: want{errStr: "cannot read CRD directory"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			path := filepath.Join(root, "source")
+
+			for file, data := range tc.args.files {
+				if err := os.MkdirAll(filepath.Join(path, filepath.Dir(file)), 0o750); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(filepath.Join(path, file), data, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tc.args.source != nil {
+				if err := os.WriteFile(path, tc.args.source, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			src, err := NewSource(logging.NewNopLogger(), ProviderConfig{
+				SourceType:      tc.args.sourceType,
+				Source:          path,
+				CRDDir:          tc.args.crdDir,
+				ContinueOnError: tc.args.continueOnError,
+			})
+			if err != nil {
+				t.Fatalf("%s\nNewSource(...): unexpected error: %v", tc.reason, err)
+			}
+
+			bf, dir, err := src.Open()
+			if tc.want.errStr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.want.errStr) {
+					t.Errorf("%s\nOpen(): want error containing %q, got %v", tc.reason, tc.want.errStr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("%s\nOpen(): unexpected error: %v", tc.reason, err)
+			}
+
+			got, summary, err := ExamineCRDs(bf, dir, []string{defaultTagFieldPath}, tc.args.continueOnError)
+			if err != nil {
+				t.Fatalf("%s\nExamineCRDs(...): unexpected error: %v", tc.reason, err)
+			}
+
+			if diff := cmp.Diff(tc.want.filters, got, cmpopts.SortSlices(func(a, b render.Filter) bool { return a.GroupKind < b.GroupKind })); diff != "" {
+				t.Errorf("%s\nOpen(): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			var errorFiles []string
+			for _, e := range summary.Errors {
+				errorFiles = append(errorFiles, e.Path)
+			}
+
+			if diff := cmp.Diff(tc.want.errorFiles, errorFiles); diff != "" {
+				t.Errorf("%s\nOpen(): -want error files, +got error files:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// testRepository is an upstream Git repository of a provider.
+type testRepository struct {
+	t    *testing.T
+	Path string
+	repo *git.Repository
+}
+
+func newTestRepository(t *testing.T) *testRepository {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "upstream")
+
+	r, err := git.PlainInit(path, false, git.WithDefaultBranch(plumbing.NewBranchReferenceName("main")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testRepository{t: t, Path: path, repo: r}
+}
+
+// Commit writes files to the repository and commits them.
+func (r *testRepository) Commit(files map[string]string) string {
+	r.t.Helper()
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+
+	for name, data := range files {
+		if err := util.WriteFile(wt.Filesystem(), name, []byte(data), 0o644); err != nil {
+			r.t.Fatal(err)
+		}
+
+		if _, err := wt.Add(name); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+
+	h, err := wt.Commit("update CRDs", &git.CommitOptions{Author: testSignature()})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+
+	return h.String()
+}
+
+// Tag creates an annotated tag of a commit.
+func (r *testRepository) Tag(name, commit string) {
+	r.t.Helper()
+
+	if _, err := r.repo.CreateTag(name, plumbing.NewHash(commit), &git.CreateTagOptions{Tagger: testSignature(), Message: name}); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+func testSignature() *object.Signature {
+	return &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(0, 0)}
+}
+
+func TestGeneratorOpen(t *testing.T) {
+	const (
+		bucketFile      = "package/crds/s3.aws.upbound.io_buckets.yaml"
+		certificateFile = "package/crds/acmpca.aws.upbound.io_certificates.yaml"
+	)
+
+	// The Bucket supports tags from the second commit
+	untaggedBucket := strings.Replace(sourceBucketCRD, "tags:", "region:", 1)
+
+	type args struct {
+		ref string
+		// update commits to the upstream repository after it was cloned
+		update bool
+	}
+
+	type want struct {
+		filters render.FilterList
+		rev     func(commits []string) Revision
+		errStr  string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Branch": {
+			reason: "A branch is checked out at its latest commit",
+			args:   args{ref: defaultRef},
+			want: want{
+				filters: render.FilterList{
+					{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+					{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+				},
+				rev: func(commits []string) Revision { return Revision{Commit: commits[1]} },
+			},
+		},
+		"Tag": {
+			reason: "A tag is checked out and recorded as the version",
+			args:   args{ref: "v1.0.0"},
+			want: want{
+				filters: render.FilterList{
+					{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+					{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: false},
+				},
+				rev: func(commits []string) Revision { return Revision{Commit: commits[0], Version: "v1.0.0"} },
+			},
+		},
+		"AbbreviatedCommit": {
+			reason: "A commit is checked out and its tag is recorded as the version",
+			args:   args{ref: "COMMIT0"},
+			want: want{
+				filters: render.FilterList{
+					{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+					{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: false},
+				},
+				rev: func(commits []string) Revision { return Revision{Commit: commits[0], Version: "v1.0.0"} },
+			},
+		},
+		"StaleCache": {
+			reason: "A cached repository is fetched before a branch is checked out",
+			args:   args{ref: defaultRef, update: true},
+			want: want{
+				filters: render.FilterList{
+					{GroupKind: "acmpca.aws.upbound.io/Certificate", Enabled: false},
+					{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: false},
+				},
+				rev: func(commits []string) Revision { return Revision{Commit: commits[2]} },
+			},
+		},
+		"UnknownReference": {
+			reason: "A reference that is not in the repository is an error",
+			args:   args{ref: "v9.9.9"},
+			want:   want{errStr: `reference "v9.9.9" is not a tag, branch or commit of the repository`},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			upstream := newTestRepository(t)
+			commits := []string{
+				upstream.Commit(map[string]string{bucketFile: untaggedBucket, certificateFile: sourceCertificateCRD}),
+				upstream.Commit(map[string]string{bucketFile: sourceBucketCRD}),
+			}
+			upstream.Tag("v1.0.0", commits[0])
+
+			ref := strings.Replace(tc.args.ref, "COMMIT0", commits[0][:10], 1)
+
+			p := ProviderConfig{Name: "provider-upjet-aws", Source: upstream.Path, Ref: ref}
+			p.SetDefaults()
+			p.ResolvePaths(t.TempDir())
+
+			log := logging.NewNopLogger()
+
+			if tc.args.update {
+				src, err := NewSource(log, p)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, _, err := src.Open(); err != nil {
+					t.Fatal(err)
+				}
+
+				commits = append(commits, upstream.Commit(map[string]string{bucketFile: untaggedBucket}))
+			}
+
+			src, err := NewSource(log, p)
+			if err != nil {
+				t.Fatalf("%s\nNewSource(...): unexpected error: %v", tc.reason, err)
+			}
+
+			bf, _, err := src.Open()
+			if tc.want.errStr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.want.errStr) {
+					t.Errorf("%s\nOpen(): want error containing %q, got %v", tc.reason, tc.want.errStr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("%s\nOpen(): unexpected error: %v", tc.reason, err)
+			}
+
+			g, ok := src.(Generator)
+			if !ok {
+				t.Fatalf("%s\nNewSource(...): want a Generator, got %T", tc.reason, src)
+			}
+
+			filters, rev, err := g.Examine(bf, []string{defaultTagFieldPath}, p.Name, nil)
+			if err != nil {
+				t.Fatalf("%s\nExamine(...): unexpected error: %v", tc.reason, err)
+			}
+
+			if diff := cmp.Diff(tc.want.filters, filters, cmpopts.SortSlices(func(a, b render.Filter) bool { return a.GroupKind < b.GroupKind })); diff != "" {
+				t.Errorf("%s\nExamine(...): -want filters, +got filters:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.rev(commits), rev); diff != "" {
+				t.Errorf("%s\nExamine(...): -want revision, +got revision:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
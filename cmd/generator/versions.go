@@ -0,0 +1,75 @@
+package main
+
+import (
+	"github.com/crossplane-contrib/function-tag-manager/cmd/generator/render"
+)
+
+// ProviderScan is the filter list of a version of a provider.
+type ProviderScan struct {
+	Version string
+	Filters render.FilterList
+}
+
+// MergeProviderScans returns the filter list of the latest scan, with the
+// range of versions in which each kind supports tags. Scans are ordered
+// from the oldest to the latest version. A range is only recorded for kinds
+// whose tag support changed between the scanned versions, and covers the
+// latest run of versions that support tags.
+func MergeProviderScans(provider string, scans []ProviderScan) render.FilterList {
+	if len(scans) == 0 {
+		return render.FilterList{}
+	}
+
+	enabled := make([]map[string]bool, len(scans))
+	for i, s := range scans {
+		enabled[i] = make(map[string]bool, len(s.Filters))
+		for _, f := range s.Filters {
+			enabled[i][f.Key()] = f.Enabled
+		}
+	}
+
+	// An API version without an entry of its own in a scan has the support
+	// of its kind
+	supported := func(i int, f render.Filter) bool {
+		if e, ok := enabled[i][f.Key()]; ok {
+			return e
+		}
+
+		return enabled[i][f.GroupKind]
+	}
+
+	latest := scans[len(scans)-1].Filters
+	merged := make(render.FilterList, 0, len(latest))
+
+	for _, f := range latest {
+		f.Provider = provider
+
+		// Find the latest run of versions that support tags
+		last := -1
+		for i := len(scans) - 1; i >= 0; i-- {
+			if supported(i, f) {
+				last = i
+				break
+			}
+		}
+
+		first := last
+		for first > 0 && supported(first-1, f) {
+			first--
+		}
+
+		if last >= 0 {
+			if first > 0 {
+				f.MinVersion = scans[first].Version
+			}
+
+			if last < len(scans)-1 {
+				f.MaxVersion = scans[last+1].Version
+			}
+		}
+
+		merged = append(merged, f)
+	}
+
+	return merged
+}
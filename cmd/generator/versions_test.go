@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/cmd/generator/render"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMergeProviderScans(t *testing.T) {
+	scans := []ProviderScan{
+		{Version: "v2.5.0", Filters: render.FilterList{
+			{GroupKind: "cloudfront.aws.upbound.io/Function"},
+			{GroupKind: "ec2.aws.upbound.io/Instance", Enabled: true},
+			{GroupKind: "ec2.aws.upbound.io/Legacy", Enabled: true},
+			{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+		}},
+		{Version: "v2.6.0", Filters: render.FilterList{
+			{GroupKind: "cloudfront.aws.upbound.io/Function"},
+			{GroupKind: "ec2.aws.upbound.io/Instance", Enabled: true},
+			{GroupKind: "ec2.aws.upbound.io/Instance", APIVersion: "v1beta1"},
+			{GroupKind: "ec2.aws.upbound.io/Legacy"},
+			{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+		}},
+		{Version: "v2.7.0", Filters: render.FilterList{
+			{GroupKind: "cloudfront.aws.upbound.io/Function", Enabled: true},
+			{GroupKind: "ec2.aws.upbound.io/Instance", Enabled: true},
+			{GroupKind: "ec2.aws.upbound.io/Instance", APIVersion: "v1beta1"},
+			{GroupKind: "ec2.aws.upbound.io/Legacy"},
+			{GroupKind: "ec2.aws.upbound.io/New", Enabled: true},
+			{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true},
+		}},
+	}
+
+	want := render.FilterList{
+		{GroupKind: "cloudfront.aws.upbound.io/Function", Enabled: true, Provider: "provider-upjet-aws", MinVersion: "v2.7.0"},
+		{GroupKind: "ec2.aws.upbound.io/Instance", Enabled: true, Provider: "provider-upjet-aws"},
+		{GroupKind: "ec2.aws.upbound.io/Instance", APIVersion: "v1beta1", Provider: "provider-upjet-aws", MaxVersion: "v2.6.0"},
+		{GroupKind: "ec2.aws.upbound.io/Legacy", Provider: "provider-upjet-aws", MaxVersion: "v2.6.0"},
+		{GroupKind: "ec2.aws.upbound.io/New", Enabled: true, Provider: "provider-upjet-aws", MinVersion: "v2.7.0"},
+		{GroupKind: "s3.aws.upbound.io/Bucket", Enabled: true, Provider: "provider-upjet-aws"},
+	}
+
+	got := MergeProviderScans("provider-upjet-aws", scans)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MergeProviderScans(...): -want, +got:\n%s", diff)
+	}
+
+	if got := MergeProviderScans("provider-upjet-aws", nil); len(got) != 0 {
+		t.Errorf("MergeProviderScans(...): want no filters without scans, got %v", got)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane-contrib/function-tag-manager/filters/crd"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/request"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/gobuffalo/flect"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+)
+
+// The kind of the CustomResourceDefinitions required from Crossplane.
+const (
+	crdAPIVersion = "apiextensions.k8s.io/v1"
+	crdKind       = "CustomResourceDefinition"
+)
+
+// CRDName returns the name of the CustomResourceDefinition of a kind, like
+// buckets.s3.aws.upbound.io. The kind is pluralized like controller-gen and
+// upjet do when they name CRDs.
+func CRDName(gvk schema.GroupVersionKind) string {
+	return strings.ToLower(flect.Pluralize(gvk.Kind)) + "." + gvk.Group
+}
+
+// RequireCRDs asks Crossplane for the CustomResourceDefinitions of the
+// kinds of Desired Composed Resources. Crossplane calls the function again
+// with the CRDs it found.
+func RequireCRDs(rsp *fnv1.RunFunctionResponse, desired map[resource.Name]*resource.DesiredComposed) {
+	for _, dc := range desired {
+		if IgnoreResource(dc) {
+			continue
+		}
+
+		name := CRDName(dc.Resource.GroupVersionKind())
+
+		if rsp.Requirements == nil {
+			rsp.Requirements = &fnv1.Requirements{}
+		}
+
+		if rsp.Requirements.Resources == nil {
+			rsp.Requirements.Resources = make(map[string]*fnv1.ResourceSelector)
+		}
+
+		rsp.Requirements.Resources[name] = &fnv1.ResourceSelector{
+			ApiVersion: crdAPIVersion,
+			Kind:       crdKind,
+			Match:      &fnv1.ResourceSelector_MatchName{MatchName: name},
+		}
+	}
+}
+
+// RequiredCRDs returns the CustomResourceDefinitions Crossplane sent with a
+// request, keyed by name. CRDs are read from the required resources, or
+// the extra resources of older Crossplane releases.
+func RequiredCRDs(req *fnv1.RunFunctionRequest) (map[string]*extv1.CustomResourceDefinition, error) {
+	required, err := request.GetRequiredResources(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get required resources")
+	}
+
+	extra, err := request.GetExtraResources(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get extra resources")
+	}
+
+	crds := make(map[string]*extv1.CustomResourceDefinition)
+
+	for _, rs := range []map[string][]resource.Required{extra, required} {
+		for _, items := range rs {
+			for _, r := range items {
+				if r.Resource.GetKind() != crdKind {
+					continue
+				}
+
+				c := &extv1.CustomResourceDefinition{}
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(r.Resource.Object, c); err != nil {
+					return nil, errors.Wrapf(err, "cannot convert CustomResourceDefinition %q", r.Resource.GetName())
+				}
+
+				crds[c.GetName()] = c
+			}
+		}
+	}
+
+	return crds, nil
+}
+
+// cachedVerdict is the verdict of a version of a CustomResourceDefinition.
+type cachedVerdict struct {
+	uid             string
+	resourceVersion string
+	verdict         crd.Verdict
+}
+
+// VerdictCache caches whether a GVK supports tags, so the schema of a CRD
+// is only walked again when the CRD changes. The zero value is ready to use.
+type VerdictCache struct {
+	mu       sync.Mutex
+	verdicts map[schema.GroupVersionKind]cachedVerdict
+}
+
+// Get returns the verdict of a GVK from its CustomResourceDefinition.
+func (c *VerdictCache) Get(gvk schema.GroupVersionKind, def *extv1.CustomResourceDefinition, tagField string) (crd.Verdict, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.verdicts[gvk]; ok && v.uid == string(def.GetUID()) && v.resourceVersion == def.GetResourceVersion() {
+		return v.verdict, nil
+	}
+
+	version, err := crd.GetServedVersion(*def, gvk.Version)
+	if err != nil {
+		return crd.Verdict{}, errors.Wrapf(err, "cannot determine version of CustomResourceDefinition %q", def.GetName())
+	}
+
+	verdict := crd.Verdict{}
+	if version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+		verdict = crd.Examine(version.Schema.OpenAPIV3Schema, tagField)
+	}
+
+	if c.verdicts == nil {
+		c.verdicts = make(map[schema.GroupVersionKind]cachedVerdict)
+	}
+
+	c.verdicts[gvk] = cachedVerdict{uid: string(def.GetUID()), resourceVersion: def.GetResourceVersion(), verdict: verdict}
+
+	return verdict, nil
+}
+
+// DiscoverTagSupport overrides the generated filter and tag paths with the
+// verdicts of the CustomResourceDefinitions of Desired Composed Resources.
+// Kinds without a CRD keep their generated entries. It returns the names of
+// resources whose CRD could not be examined.
+func DiscoverTagSupport(desired map[resource.Name]*resource.DesiredComposed, crds map[string]*extv1.CustomResourceDefinition, cache *VerdictCache, filter filters.ResourceFilter, tagPaths filters.TagPaths) map[resource.Name]error {
+	errs := make(map[resource.Name]error)
+
+	for name, dc := range desired {
+		if IgnoreResource(dc) {
+			continue
+		}
+
+		gvk := dc.Resource.GroupVersionKind()
+
+		def, ok := crds[CRDName(gvk)]
+		if !ok {
+			continue
+		}
+
+		generated := tagPaths.GetGVK(gvk)
+
+		verdict, err := cache.Get(gvk, def, tagField(generated[0]))
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+
+		// The verdict is for the API version of the resource, so it must not
+		// override the entries of other versions of the kind
+		filter[filters.GroupVersionKindKey(gvk)] = verdict.Enabled
+
+		// Keep generated paths that match the schema, the discovered paths
+		// replace them when the CRD adds, removes or reshapes a path
+		if len(verdict.TagPaths) > 0 && !sameTagPaths(verdict.TagPaths, generated) {
+			tagPaths[filters.GroupVersionKindKey(gvk)] = withDefaults(verdict.TagPaths, generated)
+		}
+	}
+
+	return errs
+}
+
+// tagField returns the name of the field of a tag path, like tags or labels.
+func tagField(p filters.TagPath) string {
+	return p.Desired[strings.LastIndex(p.Desired, ".")+1:]
+}
+
+// sameTagPaths returns true if two lists of tag paths have the same fields
+// and shapes.
+func sameTagPaths(a, b []filters.TagPath) bool {
+	return slices.EqualFunc(a, b, func(x, y filters.TagPath) bool {
+		return x.Desired == y.Desired && x.Observed == y.Observed && x.GetShape() == y.GetShape()
+	})
+}
+
+// withDefaults returns discovered tag paths with the defaults of the
+// generated paths of the same fields, like propagateAtLaunch.
+func withDefaults(discovered, generated []filters.TagPath) []filters.TagPath {
+	paths := make([]filters.TagPath, 0, len(discovered))
+
+	for _, p := range discovered {
+		for _, g := range generated {
+			if g.Desired == p.Desired && g.GetShape() == p.GetShape() {
+				p.Defaults = g.Defaults
+			}
+		}
+
+		paths = append(paths, p)
+	}
+
+	return paths
+}
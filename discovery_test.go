@@ -0,0 +1,328 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCRDName(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		gvk    schema.GroupVersionKind
+		want   string
+	}{
+		"Bucket": {
+			reason: "The plural of a kind is its lowercase name with an s",
+			gvk:    schema.GroupVersionKind{Group: "s3.aws.upbound.io", Version: "v1beta1", Kind: "Bucket"},
+			want:   "buckets.s3.aws.upbound.io",
+		},
+		"Policy": {
+			reason: "Kinds ending in y are pluralized with ies",
+			gvk:    schema.GroupVersionKind{Group: "iam.aws.upbound.io", Version: "v1beta1", Kind: "Policy"},
+			want:   "policies.iam.aws.upbound.io",
+		},
+		"InternetGateway": {
+			reason: "Kinds ending in way are pluralized with s",
+			gvk:    schema.GroupVersionKind{Group: "ec2.aws.upbound.io", Version: "v1beta1", Kind: "InternetGateway"},
+			want:   "internetgateways.ec2.aws.upbound.io",
+		},
+		"NATGateway": {
+			reason: "Kinds with an uppercase acronym are lowercased after they are pluralized",
+			gvk:    schema.GroupVersionKind{Group: "ec2.aws.upbound.io", Version: "v1beta1", Kind: "NATGateway"},
+			want:   "natgateways.ec2.aws.upbound.io",
+		},
+		"Key": {
+			reason: "Kinds ending in ey are pluralized with s",
+			gvk:    schema.GroupVersionKind{Group: "kms.aws.upbound.io", Version: "v1beta1", Kind: "Key"},
+			want:   "keys.kms.aws.upbound.io",
+		},
+		"APIKey": {
+			reason: "Kinds ending in an acronym and ey are pluralized with s",
+			gvk:    schema.GroupVersionKind{Group: "apigateway.aws.upbound.io", Version: "v1beta1", Kind: "APIKey"},
+			want:   "apikeys.apigateway.aws.upbound.io",
+		},
+		"Analysis": {
+			reason: "Kinds ending in is are pluralized with es",
+			gvk:    schema.GroupVersionKind{Group: "sagemaker.aws.upbound.io", Version: "v1beta1", Kind: "Analysis"},
+			want:   "analyses.sagemaker.aws.upbound.io",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := CRDName(tc.gvk); got != tc.want {
+				t.Errorf("%s\nCRDName(...): want %q, got %q", tc.reason, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDiscoverTagSupport(t *testing.T) {
+	newCRD := func(name, group, kind, resourceVersion string, forProvider map[string]extv1.JSONSchemaProps) *extv1.CustomResourceDefinition {
+		return &extv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: resourceVersion},
+			Spec: extv1.CustomResourceDefinitionSpec{
+				Group: group,
+				Names: extv1.CustomResourceDefinitionNames{Kind: kind},
+				Versions: []extv1.CustomResourceDefinitionVersion{{
+					Name:    "v1beta1",
+					Served:  true,
+					Storage: true,
+					Schema: &extv1.CustomResourceValidation{OpenAPIV3Schema: &extv1.JSONSchemaProps{
+						Type: "object",
+						Properties: map[string]extv1.JSONSchemaProps{
+							"spec": {Type: "object", Properties: map[string]extv1.JSONSchemaProps{
+								"forProvider": {Type: "object", Properties: forProvider},
+							}},
+						},
+					}},
+				}},
+			},
+		}
+	}
+
+	newDesired := func(apiVersion, kind string) *resource.DesiredComposed {
+		return &resource.DesiredComposed{Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+		}}}}
+	}
+
+	tagsMap := map[string]extv1.JSONSchemaProps{"tags": {Type: "object"}}
+	tagList := map[string]extv1.JSONSchemaProps{"tag": {
+		Type: "array",
+		Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"key":   {Type: "string"},
+				"value": {Type: "string"},
+			},
+		}},
+	}}
+
+	nestedTags := map[string]extv1.JSONSchemaProps{
+		"tags":       {Type: "object", AdditionalProperties: &extv1.JSONSchemaPropsOrBool{Schema: &extv1.JSONSchemaProps{Type: "string"}}},
+		"volumeTags": {Type: "object", AdditionalProperties: &extv1.JSONSchemaPropsOrBool{Schema: &extv1.JSONSchemaProps{Type: "string"}}},
+	}
+	instancePaths := []filters.TagPath{
+		filters.DefaultTagPath,
+		{Desired: "spec.forProvider.volumeTags", Observed: "status.atProvider.volumeTags"},
+	}
+	discoveredInstancePaths := []filters.TagPath{
+		{Desired: "spec.forProvider.tags", Observed: "status.atProvider.tags", Shape: filters.TagShapeMap},
+		{Desired: "spec.forProvider.volumeTags", Observed: "status.atProvider.volumeTags", Shape: filters.TagShapeMap},
+	}
+	tagListPath := filters.TagPath{
+		Desired:  "spec.forProvider.tag",
+		Observed: "status.atProvider.tag",
+		Shape:    filters.TagShapeKeyValueList,
+	}
+
+	type args struct {
+		desired  map[resource.Name]*resource.DesiredComposed
+		crds     map[string]*extv1.CustomResourceDefinition
+		tagPaths filters.TagPaths
+	}
+
+	type want struct {
+		filter   filters.ResourceFilter
+		tagPaths filters.TagPaths
+		errs     int
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NewKind": {
+			reason: "A kind missing from the generated filter supports tags if its CRD has tags",
+			args: args{
+				desired: map[resource.Name]*resource.DesiredComposed{"widget": newDesired("example.aws.upbound.io/v1beta1", "Widget")},
+				crds: map[string]*extv1.CustomResourceDefinition{
+					"widgets.example.aws.upbound.io": newCRD("widgets.example.aws.upbound.io", "example.aws.upbound.io", "Widget", "1", tagsMap),
+				},
+			},
+			want: want{
+				filter:   filters.ResourceFilter{"s3.aws.upbound.io/Bucket": true, "example.aws.upbound.io/v1beta1/Widget": true},
+				tagPaths: filters.TagPaths{},
+			},
+		},
+		"RemovedTags": {
+			reason: "The CRD takes precedence over the generated filter for the API version of the resource",
+			args: args{
+				desired: map[resource.Name]*resource.DesiredComposed{"bucket": newDesired("s3.aws.upbound.io/v1beta1", "Bucket")},
+				crds: map[string]*extv1.CustomResourceDefinition{
+					"buckets.s3.aws.upbound.io": newCRD("buckets.s3.aws.upbound.io", "s3.aws.upbound.io", "Bucket", "1", nil),
+				},
+			},
+			want: want{
+				filter:   filters.ResourceFilter{"s3.aws.upbound.io/Bucket": true, "s3.aws.upbound.io/v1beta1/Bucket": false},
+				tagPaths: filters.TagPaths{},
+			},
+		},
+		"MissingCRD": {
+			reason: "Kinds without a CRD keep their generated entries",
+			args: args{
+				desired: map[resource.Name]*resource.DesiredComposed{"bucket": newDesired("s3.aws.upbound.io/v1beta1", "Bucket")},
+			},
+			want: want{
+				filter:   filters.ResourceFilter{"s3.aws.upbound.io/Bucket": true},
+				tagPaths: filters.TagPaths{},
+			},
+		},
+		"KeyValueList": {
+			reason: "Kinds with a list of key/value tags get the tag path of the list",
+			args: args{
+				desired: map[resource.Name]*resource.DesiredComposed{"gadget": newDesired("example.aws.upbound.io/v1beta1", "Gadget")},
+				crds: map[string]*extv1.CustomResourceDefinition{
+					"gadgets.example.aws.upbound.io": newCRD("gadgets.example.aws.upbound.io", "example.aws.upbound.io", "Gadget", "1", tagList),
+				},
+			},
+			want: want{
+				filter:   filters.ResourceFilter{"s3.aws.upbound.io/Bucket": true, "example.aws.upbound.io/v1beta1/Gadget": true},
+				tagPaths: filters.TagPaths{"example.aws.upbound.io/v1beta1/Gadget": {tagListPath}},
+			},
+		},
+		"NestedTagPaths": {
+			reason: "Kinds that keep tags in more than one field get every discovered tag path",
+			args: args{
+				desired: map[resource.Name]*resource.DesiredComposed{"instance": newDesired("ec2.aws.upbound.io/v1beta1", "Instance")},
+				crds: map[string]*extv1.CustomResourceDefinition{
+					"instances.ec2.aws.upbound.io": newCRD("instances.ec2.aws.upbound.io", "ec2.aws.upbound.io", "Instance", "1", nestedTags),
+				},
+			},
+			want: want{
+				filter:   filters.ResourceFilter{"s3.aws.upbound.io/Bucket": true, "ec2.aws.upbound.io/v1beta1/Instance": true},
+				tagPaths: filters.TagPaths{"ec2.aws.upbound.io/v1beta1/Instance": discoveredInstancePaths},
+			},
+		},
+		"SameTagPaths": {
+			reason: "Generated tag paths that match the CRD are kept",
+			args: args{
+				desired: map[resource.Name]*resource.DesiredComposed{"instance": newDesired("ec2.aws.upbound.io/v1beta1", "Instance")},
+				crds: map[string]*extv1.CustomResourceDefinition{
+					"instances.ec2.aws.upbound.io": newCRD("instances.ec2.aws.upbound.io", "ec2.aws.upbound.io", "Instance", "1", nestedTags),
+				},
+				tagPaths: filters.TagPaths{"ec2.aws.upbound.io/Instance": instancePaths},
+			},
+			want: want{
+				filter:   filters.ResourceFilter{"s3.aws.upbound.io/Bucket": true, "ec2.aws.upbound.io/v1beta1/Instance": true},
+				tagPaths: filters.TagPaths{"ec2.aws.upbound.io/Instance": instancePaths},
+			},
+		},
+		"GeneratedDefaults": {
+			reason: "Discovered tag paths keep the defaults of the generated paths of the same field",
+			args: args{
+				desired: map[resource.Name]*resource.DesiredComposed{"gadget": newDesired("example.aws.upbound.io/v1beta1", "Gadget")},
+				crds: map[string]*extv1.CustomResourceDefinition{
+					"gadgets.example.aws.upbound.io": newCRD("gadgets.example.aws.upbound.io", "example.aws.upbound.io", "Gadget", "1", tagList),
+				},
+				tagPaths: filters.TagPaths{"example.aws.upbound.io/Gadget": {
+					{Desired: "spec.forProvider.tag", Observed: "status.atProvider.tag", Shape: filters.TagShapeKeyValueList, Defaults: map[string]any{"propagateAtLaunch": true}},
+					{Desired: "spec.forProvider.launchTemplate.tags", Observed: "status.atProvider.launchTemplate.tags"},
+				}},
+			},
+			want: want{
+				filter: filters.ResourceFilter{"s3.aws.upbound.io/Bucket": true, "example.aws.upbound.io/v1beta1/Gadget": true},
+				tagPaths: filters.TagPaths{
+					"example.aws.upbound.io/Gadget": {
+						{Desired: "spec.forProvider.tag", Observed: "status.atProvider.tag", Shape: filters.TagShapeKeyValueList, Defaults: map[string]any{"propagateAtLaunch": true}},
+						{Desired: "spec.forProvider.launchTemplate.tags", Observed: "status.atProvider.launchTemplate.tags"},
+					},
+					"example.aws.upbound.io/v1beta1/Gadget": {
+						{Desired: "spec.forProvider.tag", Observed: "status.atProvider.tag", Shape: filters.TagShapeKeyValueList, Defaults: map[string]any{"propagateAtLaunch": true}},
+					},
+				},
+			},
+		},
+		"NoStorageVersion": {
+			reason: "A CRD without a usable version is an error, and the kind keeps its generated entry",
+			args: args{
+				desired: map[resource.Name]*resource.DesiredComposed{"bucket": newDesired("s3.aws.upbound.io/v1beta2", "Bucket")},
+				crds: map[string]*extv1.CustomResourceDefinition{
+					"buckets.s3.aws.upbound.io": {ObjectMeta: metav1.ObjectMeta{Name: "buckets.s3.aws.upbound.io"}},
+				},
+			},
+			want: want{
+				filter:   filters.ResourceFilter{"s3.aws.upbound.io/Bucket": true},
+				tagPaths: filters.TagPaths{},
+				errs:     1,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			filter := filters.ResourceFilter{"s3.aws.upbound.io/Bucket": true}
+			tagPaths := filters.TagPaths{}
+			for k, v := range tc.args.tagPaths {
+				tagPaths[k] = v
+			}
+
+			errs := DiscoverTagSupport(tc.args.desired, tc.args.crds, &VerdictCache{}, filter, tagPaths)
+
+			if diff := cmp.Diff(tc.want.filter, filter); diff != "" {
+				t.Errorf("%s\nDiscoverTagSupport(...): -want filter, +got filter:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.tagPaths, tagPaths); diff != "" {
+				t.Errorf("%s\nDiscoverTagSupport(...): -want tag paths, +got tag paths:\n%s", tc.reason, diff)
+			}
+
+			if len(errs) != tc.want.errs {
+				t.Errorf("%s\nDiscoverTagSupport(...): want %d errors, got %v", tc.reason, tc.want.errs, errs)
+			}
+		})
+	}
+}
+
+func TestVerdictCache(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.aws.upbound.io", Version: "v1beta1", Kind: "Widget"}
+
+	def := &extv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.aws.upbound.io", ResourceVersion: "1"},
+		Spec: extv1.CustomResourceDefinitionSpec{Versions: []extv1.CustomResourceDefinitionVersion{{
+			Name:    "v1beta1",
+			Served:  true,
+			Storage: true,
+			Schema: &extv1.CustomResourceValidation{OpenAPIV3Schema: &extv1.JSONSchemaProps{
+				Properties: map[string]extv1.JSONSchemaProps{
+					"spec": {Properties: map[string]extv1.JSONSchemaProps{
+						"forProvider": {Properties: map[string]extv1.JSONSchemaProps{"tags": {Type: "object"}}},
+					}},
+				},
+			}},
+		}}},
+	}
+
+	c := &VerdictCache{}
+
+	if v, err := c.Get(gvk, def, "tags"); err != nil || !v.Enabled {
+		t.Fatalf("Get(...): want enabled verdict, got %v, %v", v, err)
+	}
+
+	// The same resource version is not examined again
+	unchanged := def.DeepCopy()
+	unchanged.Spec.Versions[0].Schema = nil
+
+	if v, _ := c.Get(gvk, unchanged, "tags"); !v.Enabled {
+		t.Errorf("Get(...): want cached enabled verdict, got %v", v)
+	}
+
+	// A new resource version is examined again
+	changed := unchanged.DeepCopy()
+	changed.ResourceVersion = "2"
+
+	if v, _ := c.Get(gvk, changed, "tags"); v.Enabled {
+		t.Errorf("Get(...): want disabled verdict of the changed CRD, got %v", v)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// TagDiff contains the tag keys that differ between two versions of a resource.
+type TagDiff struct {
+	// Added are keys that are only in the new tags.
+	Added []string
+	// Changed are keys that have a different value in the new tags.
+	Changed []string
+	// Removed are keys that are only in the old tags.
+	Removed []string
+}
+
+// DiffTags returns the keys that were added, changed and removed between the
+// tags in a tag path of two Desired Composed Resources.
+func DiffTags(before, after *resource.DesiredComposed, p filters.TagPath) TagDiff {
+	beforeTags, _ := GetTags(before.Resource.Object, p.Desired, p.GetShape())
+	afterTags, _ := GetTags(after.Resource.Object, p.Desired, p.GetShape())
+
+	diff := TagDiff{}
+
+	for _, k := range slices.Sorted(maps.Keys(afterTags)) {
+		v, ok := beforeTags[k]
+
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, k)
+		case v != afterTags[k]:
+			diff.Changed = append(diff.Changed, k)
+		}
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(beforeTags)) {
+		if _, ok := afterTags[k]; !ok {
+			diff.Removed = append(diff.Removed, k)
+		}
+	}
+
+	return diff
+}
+
+// FormatTagDiffs returns a summary of the tag changes in every tag path of
+// two Desired Composed Resources. Changes in paths other than the primary
+// path are prefixed with the path.
+func FormatTagDiffs(before, after *resource.DesiredComposed, paths []filters.TagPath) string {
+	parts := []string{DiffTags(before, after, paths[0]).String()}
+
+	for _, p := range paths[1:] {
+		if diff := DiffTags(before, after, p); !diff.Empty() {
+			parts = append(parts, fmt.Sprintf("%s: %s", p.Desired, diff))
+		}
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// Empty returns true if there are no differences.
+func (d TagDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// String returns a human-readable summary of the differences.
+func (d TagDiff) String() string {
+	if d.Empty() {
+		return "no changes"
+	}
+
+	parts := make([]string, 0, 3)
+
+	for _, p := range []struct {
+		verb string
+		keys []string
+	}{
+		{verb: "add", keys: d.Added},
+		{verb: "change", keys: d.Changed},
+		{verb: "remove", keys: d.Removed},
+	} {
+		if len(p.keys) > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", p.verb, strings.Join(p.keys, ", ")))
+		}
+	}
+
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDiffTags(t *testing.T) {
+	withTags := func(tags map[string]any) *resource.DesiredComposed {
+		return &resource.DesiredComposed{
+			Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+				Object: map[string]any{
+					"spec": map[string]any{
+						"forProvider": map[string]any{
+							"tags": tags,
+						},
+					},
+				},
+			}},
+		}
+	}
+
+	type want struct {
+		diff    TagDiff
+		summary string
+	}
+
+	cases := map[string]struct {
+		reason string
+		before *resource.DesiredComposed
+		after  *resource.DesiredComposed
+		want   want
+	}{
+		"NoChanges": {
+			reason: "Identical tags have no differences",
+			before: withTags(map[string]any{"owner": "platform"}),
+			after:  withTags(map[string]any{"owner": "platform"}),
+			want:   want{summary: "no changes"},
+		},
+		"NoTagsBefore": {
+			reason: "Every tag is added to a resource without tags",
+			before: &resource.DesiredComposed{Resource: composed.New()},
+			after:  withTags(map[string]any{"owner": "platform", "environment": "prod"}),
+			want: want{
+				diff:    TagDiff{Added: []string{"environment", "owner"}},
+				summary: "add environment, owner",
+			},
+		},
+		"AddChangeRemove": {
+			reason: "Added, changed and removed keys are reported",
+			before: withTags(map[string]any{"environment": "dev", "old": "value", "owner": "platform"}),
+			after:  withTags(map[string]any{"environment": "prod", "owner": "platform", "team": "network"}),
+			want: want{
+				diff:    TagDiff{Added: []string{"team"}, Changed: []string{"environment"}, Removed: []string{"old"}},
+				summary: "add team; change environment; remove old",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := DiffTags(tc.before, tc.after, filters.DefaultTagPath)
+
+			if diff := cmp.Diff(tc.want.diff, got); diff != "" {
+				t.Errorf("%s\nDiffTags(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			if got.String() != tc.want.summary {
+				t.Errorf("%s\nTagDiff.String(): want %q, got %q", tc.reason, tc.want.summary, got.String())
+			}
+		})
+	}
+}
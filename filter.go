@@ -1,19 +1,72 @@
 package main
 
 import (
+	"path"
+
 	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
 	"github.com/crossplane/function-sdk-go/resource"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
 )
 
-// SupportedManagedResource returns true if a resource supports tags.
-func SupportedManagedResource(desired *resource.DesiredComposed, filter filters.ResourceFilter) bool {
+// MatchResource returns whether a resource supports tags, and whether the
+// input or an entry of the filter matched its kind.
+func MatchResource(desired *resource.DesiredComposed, filter filters.ResourceFilter, in *v1beta1.ResourceFilter) (supported, matched bool) {
 	gvk := desired.Resource.GroupVersionKind()
+	groupKind := filters.GroupKindKey(gvk)
+
+	if in != nil {
+		if matchAny(in.Exclude, groupKind) {
+			return false, true
+		}
+
+		if matchAny(in.Include, groupKind) {
+			return true, true
+		}
+	}
+
+	return filter.MatchGVK(gvk)
+}
+
+// UnknownKindSupported returns whether a resource of a kind that matched no
+// filter supports tags, and whether that was detected from its tags at the
+// tag path p.
+func UnknownKindSupported(policy v1beta1.UnknownKindPolicy, desired *resource.DesiredComposed, observed *resource.ObservedComposed, p filters.TagPath) (supported, detected bool) {
+	switch policy {
+	case v1beta1.UnknownKindsTag:
+		return true, false
+	case v1beta1.UnknownKindsDetect:
+		detected := HasTags(desired.Resource.Object, p.Desired, p.GetShape())
+		if !detected && observed != nil && observed.Resource != nil {
+			detected = HasTags(observed.Resource.Object, p.Observed, p.GetShape())
+		}
+
+		return detected, detected
+	case v1beta1.UnknownKindsSkip:
+	}
+
+	return false, false
+}
+
+// HasTags returns true if an object has tags at a field path, even if
+// there are no tags in them.
+func HasTags(obj map[string]any, fp string, shape filters.TagShape) bool {
+	_, err := GetTags(obj, fp, shape)
+	return err == nil
+}
+
+// ValidateResourceFilter returns an error if a pattern of the input is malformed.
+func ValidateResourceFilter(in *v1beta1.ResourceFilter) error {
+	if in == nil {
+		return nil
+	}
 
-	resource := gvk.Group + "/" + gvk.Kind
-	if val, ok := filter[resource]; ok {
-		return val
+	for _, p := range append(append([]string{}, in.Include...), in.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return errors.Wrapf(err, "invalid resourceFilter pattern %q", p)
+		}
 	}
 
-	// Filter out any remaining resources
-	return false
+	return nil
 }
@@ -4,12 +4,13 @@ import (
 	"testing"
 
 	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
 	"github.com/crossplane/function-sdk-go/resource"
 	"github.com/crossplane/function-sdk-go/resource/composed"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
-func TestSupportedManagedResource(t *testing.T) {
+func TestMatchResourceFilter(t *testing.T) {
 	ResourceFilter := filters.NewResourceFilter()
 
 	type args struct {
@@ -392,11 +393,214 @@ func TestSupportedManagedResource(t *testing.T) {
 			},
 			want: true,
 		},
+		"APIVersionExclude": {
+			reason: "Exclude an API version of a kind that doesn't support tags in that version",
+			args: args{
+				desired: &resource.DesiredComposed{
+					Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+						Object: map[string]any{
+							"apiVersion": "ec2.aws.upbound.io/v1beta1",
+							"kind":       "Instance",
+							"metadata": map[string]any{
+								"name": "test-instance",
+							},
+						},
+					}},
+				},
+				filter: filters.ResourceFilter{
+					"ec2.aws.upbound.io/Instance":         true,
+					"ec2.aws.upbound.io/v1beta1/Instance": false,
+				},
+			},
+			want: false,
+		},
+		"APIVersionFallback": {
+			reason: "Include an API version without an entry of a kind that supports tags",
+			args: args{
+				desired: &resource.DesiredComposed{
+					Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+						Object: map[string]any{
+							"apiVersion": "ec2.aws.upbound.io/v1beta2",
+							"kind":       "Instance",
+							"metadata": map[string]any{
+								"name": "test-instance",
+							},
+						},
+					}},
+				},
+				filter: filters.ResourceFilter{
+					"ec2.aws.upbound.io/Instance":         true,
+					"ec2.aws.upbound.io/v1beta1/Instance": false,
+				},
+			},
+			want: true,
+		},
 	}
 	for name, tt := range cases {
 		t.Run(name, func(t *testing.T) {
-			if got := SupportedManagedResource(tt.args.desired, tt.args.filter); got != tt.want {
-				t.Errorf("SupportedManagedResource() = %v, want %v", got, tt.want)
+			if got, _ := MatchResource(tt.args.desired, tt.args.filter, nil); got != tt.want {
+				t.Errorf("MatchResource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchResource(t *testing.T) {
+	filter := filters.ResourceFilter{
+		"ec2.aws.upbound.io/VPC":          true,
+		"cloudfront.aws.upbound.io/Cache": false,
+	}
+
+	desired := func(apiVersion, kind string) *resource.DesiredComposed {
+		return &resource.DesiredComposed{Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+			Object: map[string]any{"apiVersion": apiVersion, "kind": kind},
+		}}}
+	}
+
+	type args struct {
+		desired *resource.DesiredComposed
+		in      *v1beta1.ResourceFilter
+	}
+
+	type want struct {
+		supported bool
+		matched   bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoOverrides": {
+			reason: "Without overrides the filter is used",
+			args:   args{desired: desired("ec2.aws.upbound.io/v1beta1", "VPC")},
+			want:   want{supported: true, matched: true},
+		},
+		"NoMatch": {
+			reason: "A kind missing from the filter is not supported and matches nothing",
+			args:   args{desired: desired("db.platform.example.com/v1", "Database")},
+			want:   want{supported: false, matched: false},
+		},
+		"IncludeExact": {
+			reason: "An included kind supports tags even if the filter disables it",
+			args: args{
+				desired: desired("cloudfront.aws.upbound.io/v1beta1", "Cache"),
+				in:      &v1beta1.ResourceFilter{Include: []string{"cloudfront.aws.upbound.io/Cache"}},
+			},
+			want: want{supported: true, matched: true},
+		},
+		"IncludePattern": {
+			reason: "Kinds missing from the filter can be included with a pattern",
+			args: args{
+				desired: desired("db.platform.example.com/v1", "Database"),
+				in:      &v1beta1.ResourceFilter{Include: []string{"*.platform.example.com/*"}},
+			},
+			want: want{supported: true, matched: true},
+		},
+		"ExcludePattern": {
+			reason: "An excluded kind does not support tags even if the filter enables it",
+			args: args{
+				desired: desired("ec2.aws.upbound.io/v1beta1", "VPC"),
+				in:      &v1beta1.ResourceFilter{Exclude: []string{"ec2.aws.upbound.io/*"}},
+			},
+			want: want{supported: false, matched: true},
+		},
+		"ExcludeBeforeInclude": {
+			reason: "Exclude takes precedence over Include",
+			args: args{
+				desired: desired("ec2.aws.upbound.io/v1beta1", "VPC"),
+				in: &v1beta1.ResourceFilter{
+					Include: []string{"ec2.aws.upbound.io/VPC"},
+					Exclude: []string{"ec2.aws.upbound.io/VPC"},
+				},
+			},
+			want: want{supported: false, matched: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			supported, matched := MatchResource(tc.args.desired, filter, tc.args.in)
+			if supported != tc.want.supported || matched != tc.want.matched {
+				t.Errorf("%s\nMatchResource(...): want supported %t, matched %t, got supported %t, matched %t", tc.reason, tc.want.supported, tc.want.matched, supported, matched)
+			}
+		})
+	}
+}
+
+func TestValidateResourceFilter(t *testing.T) {
+	if err := ValidateResourceFilter(&v1beta1.ResourceFilter{Include: []string{"*.aws.upbound.io/*"}}); err != nil {
+		t.Errorf("ValidateResourceFilter(...): unexpected error: %v", err)
+	}
+
+	if err := ValidateResourceFilter(&v1beta1.ResourceFilter{Exclude: []string{"[.aws.upbound.io/*"}}); err == nil {
+		t.Errorf("ValidateResourceFilter(...): want error for malformed pattern, got nil")
+	}
+}
+
+func TestUnknownKindSupported(t *testing.T) {
+	withTags := &resource.DesiredComposed{Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+		Object: map[string]any{"spec": map[string]any{"forProvider": map[string]any{"tags": map[string]any{}}}},
+	}}}
+
+	withoutTags := &resource.DesiredComposed{Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+		Object: map[string]any{"spec": map[string]any{"forProvider": map[string]any{}}},
+	}}}
+
+	observedWithTags := &resource.ObservedComposed{Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+		Object: map[string]any{"status": map[string]any{"atProvider": map[string]any{"tags": map[string]any{"owner": "platform"}}}},
+	}}}
+
+	type args struct {
+		policy   v1beta1.UnknownKindPolicy
+		desired  *resource.DesiredComposed
+		observed *resource.ObservedComposed
+	}
+
+	type want struct {
+		supported bool
+		detected  bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Skip": {
+			reason: "Unknown kinds are not tagged by default, even if they have tags",
+			args:   args{policy: v1beta1.UnknownKindsSkip, desired: withTags},
+			want:   want{},
+		},
+		"Tag": {
+			reason: "All unknown kinds are tagged",
+			args:   args{policy: v1beta1.UnknownKindsTag, desired: withoutTags},
+			want:   want{supported: true},
+		},
+		"DetectDesired": {
+			reason: "Unknown kinds with desired tags are tagged",
+			args:   args{policy: v1beta1.UnknownKindsDetect, desired: withTags},
+			want:   want{supported: true, detected: true},
+		},
+		"DetectObserved": {
+			reason: "Unknown kinds with observed tags are tagged",
+			args:   args{policy: v1beta1.UnknownKindsDetect, desired: withoutTags, observed: observedWithTags},
+			want:   want{supported: true, detected: true},
+		},
+		"DetectNoTags": {
+			reason: "Unknown kinds without tags are not tagged",
+			args:   args{policy: v1beta1.UnknownKindsDetect, desired: withoutTags},
+			want:   want{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			supported, detected := UnknownKindSupported(tc.args.policy, tc.args.desired, tc.args.observed, filters.DefaultTagPath)
+
+			if supported != tc.want.supported || detected != tc.want.detected {
+				t.Errorf("%s\nUnknownKindSupported(...): want %t, %t, got %t, %t", tc.reason, tc.want.supported, tc.want.detected, supported, detected)
 			}
 		})
 	}
@@ -0,0 +1,300 @@
+// Package crd determines whether a kind supports tags from the OpenAPI
+// schema of its CustomResourceDefinition.
+package crd
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/fieldpath"
+)
+
+// Field names of managed resource schemas.
+const (
+	FieldAtProvider  = "atProvider"
+	FieldForProvider = "forProvider"
+	FieldKey         = "key"
+	FieldLabels      = "labels"
+	FieldSpec        = "spec"
+	FieldStatus      = "status"
+	FieldTag         = "tag"
+	FieldTags        = "tags"
+	FieldValue       = "value"
+)
+
+// tagFieldSuffix is the suffix of the names of fields that hold other tags
+// of a kind, like volumeTags.
+const tagFieldSuffix = "Tags"
+
+// Verdict is whether a kind supports tags, and where it keeps them.
+type Verdict struct {
+	// Enabled is true if the kind supports tags.
+	Enabled bool
+	// TagPaths are set for kinds that do not keep tags in a map at
+	// spec.forProvider.tags, or that keep tags in more than one field. The
+	// first path is the primary tags of the kind.
+	TagPaths []filters.TagPath
+}
+
+// Examine determines if the tag field, like tags or labels, exists in
+// spec.forProvider of a schema, either as a map or as a list of key/value
+// objects.
+func Examine(schema *extv1.JSONSchemaProps, tagField string) Verdict {
+	return ExaminePaths(schema, []string{FieldSpec + "." + FieldForProvider + "." + tagField})
+}
+
+// ExaminePaths determines if a field path of spec.forProvider, like
+// spec.forProvider.labels, holds tags in a schema. The paths are probed in
+// order for a map, and then for a list of key/value objects. Paths that are
+// not valid tag field paths are skipped. The other fields of the kind that
+// hold a map of tags, like the volumeTags and rootBlockDevice tags of an EC2
+// Instance, follow the primary tag path.
+func ExaminePaths(schema *extv1.JSONSchemaProps, paths []string) Verdict {
+	fields := make([][]string, 0, len(paths))
+
+	for _, p := range paths {
+		if f, err := ParseTagFieldPath(p); err == nil {
+			fields = append(fields, f)
+		}
+	}
+
+	for _, f := range fields {
+		if !CheckFieldPath(schema, f) {
+			continue
+		}
+
+		return verdict(schema, f, filters.TagShapeMap)
+	}
+
+	// Otherwise look for a list of key/value tags, like spec.forProvider.tag
+	for _, f := range fields {
+		for _, field := range ListTagFields(f[len(f)-1]) {
+			list := append(slices.Clone(f[:len(f)-1]), field)
+			if CheckKeyValueListPath(schema, list) {
+				return verdict(schema, list, filters.TagShapeKeyValueList)
+			}
+		}
+	}
+
+	return Verdict{}
+}
+
+// verdict returns the verdict of a kind that keeps its primary tags at the
+// fields of a tag field path.
+func verdict(schema *extv1.JSONSchemaProps, fields []string, shape filters.TagShape) Verdict {
+	primary := tagPath(fields, shape)
+	others := otherTagPaths(schema, fields)
+
+	if len(others) == 0 && shape == filters.TagShapeMap && primary.Desired == filters.DefaultTagPath.Desired {
+		return Verdict{Enabled: true}
+	}
+
+	return Verdict{Enabled: true, TagPaths: append([]filters.TagPath{primary}, others...)}
+}
+
+// otherTagPaths returns the tag paths of the fields next to the primary tag
+// field that hold a map of tags: fields named like volumeTags, and fields
+// named like the primary tag field in objects or single-item arrays, like
+// rootBlockDevice[0].tags. The paths are sorted.
+func otherTagPaths(schema *extv1.JSONSchemaProps, primary []string) []filters.TagPath {
+	parent := GetFieldPath(schema, primary[:len(primary)-1])
+	if parent == nil {
+		return nil
+	}
+
+	name := primary[len(primary)-1]
+	paths := make([]filters.TagPath, 0)
+
+	for field, property := range parent.Properties {
+		var fields []string
+
+		switch {
+		case field == name:
+		case strings.HasSuffix(field, tagFieldSuffix) && IsStringMap(&property):
+			fields = []string{field}
+		case property.Type == "object" && IsStringMap(GetFieldPath(&property, []string{name})):
+			fields = []string{field, name}
+		case isSingleItemArray(&property) && IsStringMap(GetFieldPath(property.Items.Schema, []string{name})):
+			fields = []string{field + "[0]", name}
+		}
+
+		if len(fields) > 0 {
+			paths = append(paths, tagPath(append(slices.Clone(primary[:len(primary)-1]), fields...), filters.TagShapeMap))
+		}
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].Desired < paths[j].Desired
+	})
+
+	return paths
+}
+
+// isSingleItemArray returns true if a schema is an array of objects with at
+// most one item, like the root block device of an EC2 Instance.
+func isSingleItemArray(property *extv1.JSONSchemaProps) bool {
+	return property.Type == "array" && property.MaxItems != nil && *property.MaxItems == 1 &&
+		property.Items != nil && property.Items.Schema != nil && property.Items.Schema.Type == "object"
+}
+
+// ParseTagFieldPath returns the fields of a field path of spec.forProvider
+// that may hold tags, like spec.forProvider.labels. Paths must not index
+// arrays, because the tags of a kind are kept in a single field.
+func ParseTagFieldPath(path string) ([]string, error) {
+	segments, err := fieldpath.Parse(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid tag field path %q", path)
+	}
+
+	fields := make([]string, 0, len(segments))
+
+	for _, s := range segments {
+		if s.Type != fieldpath.SegmentField {
+			return nil, errors.Errorf("tag field path %q must not index arrays", path)
+		}
+
+		fields = append(fields, s.Field)
+	}
+
+	if len(fields) < 3 || fields[0] != FieldSpec || fields[1] != FieldForProvider {
+		return nil, errors.Errorf("tag field path %q is not a field of %s.%s", path, FieldSpec, FieldForProvider)
+	}
+
+	return fields, nil
+}
+
+// tagPath returns the TagPath of the fields of a tag field path, whose
+// observed field is in status.atProvider.
+func tagPath(fields []string, shape filters.TagShape) filters.TagPath {
+	return filters.TagPath{
+		Desired:  strings.Join(fields, "."),
+		Observed: FieldStatus + "." + FieldAtProvider + "." + strings.Join(fields[2:], "."),
+		Shape:    shape,
+	}
+}
+
+// ListTagFields returns the fields that may hold a list of key/value tags.
+func ListTagFields(tagField string) []string {
+	if tagField == FieldTags {
+		return []string{FieldTags, FieldTag}
+	}
+
+	return []string{tagField}
+}
+
+// CheckFieldPath traverses the OpenAPI schema to check if an object exists at a field path.
+func CheckFieldPath(schema *extv1.JSONSchemaProps, path []string) bool {
+	if schema == nil || len(path) == 0 {
+		return false
+	}
+	// Get the first element of the path
+	field := path[0]
+
+	// Check if the field exists in the schema properties
+	if schema.Properties == nil {
+		return false
+	}
+
+	property, exists := schema.Properties[field]
+	if !exists {
+		return false
+	}
+
+	// If this is the last element in the path, we found it
+	if len(path) == 1 {
+		return property.Type == "object"
+	}
+
+	// Otherwise, recurse into the next level
+	return CheckFieldPath(&property, path[1:])
+}
+
+// CheckKeyValueListPath traverses the OpenAPI schema to check if a list of
+// objects with string key and value fields exists at a field path. Other
+// fields of the objects, like propagateAtLaunch, are allowed.
+func CheckKeyValueListPath(schema *extv1.JSONSchemaProps, path []string) bool {
+	return IsKeyValueList(GetFieldPath(schema, path))
+}
+
+// IsKeyValueList returns true if a schema is a list of objects with string
+// key and value fields.
+func IsKeyValueList(property *extv1.JSONSchemaProps) bool {
+	if property == nil || property.Type != "array" || property.Items == nil || property.Items.Schema == nil {
+		return false
+	}
+
+	// A list of a single tag, like an autoscaling GroupTag, is not a list of tags
+	if property.MaxItems != nil && *property.MaxItems <= 1 {
+		return false
+	}
+
+	item := property.Items.Schema
+	if item.Type != "object" {
+		return false
+	}
+
+	key, hasKey := item.Properties[FieldKey]
+	value, hasValue := item.Properties[FieldValue]
+
+	return hasKey && hasValue && key.Type == "string" && value.Type == "string"
+}
+
+// GetFieldPath returns the schema of the field at a field path, or nil if
+// the field does not exist.
+func GetFieldPath(schema *extv1.JSONSchemaProps, path []string) *extv1.JSONSchemaProps {
+	for _, field := range path {
+		if schema == nil || schema.Properties == nil {
+			return nil
+		}
+
+		property, exists := schema.Properties[field]
+		if !exists {
+			return nil
+		}
+
+		schema = &property
+	}
+
+	return schema
+}
+
+// GetCRDVersion returns the Stored and Served version of the CRD.
+func GetCRDVersion(crd extv1.CustomResourceDefinition) (extv1.CustomResourceDefinitionVersion, error) {
+	for _, version := range crd.Spec.Versions {
+		if version.Served && version.Storage {
+			return version, nil
+		}
+	}
+
+	return extv1.CustomResourceDefinitionVersion{}, errors.New("no served and storage version found in CustomResourceDefinition")
+}
+
+// GetServedVersions returns the served versions of the CRD.
+func GetServedVersions(crd extv1.CustomResourceDefinition) []extv1.CustomResourceDefinitionVersion {
+	served := make([]extv1.CustomResourceDefinitionVersion, 0, len(crd.Spec.Versions))
+
+	for _, v := range crd.Spec.Versions {
+		if v.Served {
+			served = append(served, v)
+		}
+	}
+
+	return served
+}
+
+// GetServedVersion returns a served version of the CRD, or the Stored and
+// Served version if that version is not served.
+func GetServedVersion(crd extv1.CustomResourceDefinition, version string) (extv1.CustomResourceDefinitionVersion, error) {
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version && v.Served {
+			return v, nil
+		}
+	}
+
+	return GetCRDVersion(crd)
+}
@@ -0,0 +1,377 @@
+package crd
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestCheckFieldPath(t *testing.T) {
+	type args struct {
+		schema *extv1.JSONSchemaProps
+		path   []string
+	}
+
+	type want struct {
+		result bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NilSchema": {
+			reason: "Should return false for nil schema",
+			args: args{
+				schema: nil,
+				path:   []string{FieldSpec, FieldForProvider, FieldTags},
+			},
+			want: want{
+				result: false,
+			},
+		},
+		"EmptyPath": {
+			reason: "Should return false for empty path",
+			args: args{
+				schema: &extv1.JSONSchemaProps{
+					Properties: map[string]extv1.JSONSchemaProps{
+						FieldSpec: {},
+					},
+				},
+				path: []string{},
+			},
+			want: want{
+				result: false,
+			},
+		},
+		"NilProperties": {
+			reason: "Should return false when schema has nil properties",
+			args: args{
+				schema: &extv1.JSONSchemaProps{
+					Properties: nil,
+				},
+				path: []string{FieldSpec},
+			},
+			want: want{
+				result: false,
+			},
+		},
+		"SingleLevelFieldExists": {
+			reason: "Should return true when single level field exists",
+			args: args{
+				schema: &extv1.JSONSchemaProps{
+					Properties: map[string]extv1.JSONSchemaProps{
+						FieldSpec: {Type: "object"},
+					},
+				},
+				path: []string{FieldSpec},
+			},
+			want: want{
+				result: true,
+			},
+		},
+		"SingleLevelFieldDoesNotExist": {
+			reason: "Should return false when single level field does not exist",
+			args: args{
+				schema: &extv1.JSONSchemaProps{
+					Properties: map[string]extv1.JSONSchemaProps{
+						FieldSpec: {},
+					},
+				},
+				path: []string{"status"},
+			},
+			want: want{
+				result: false,
+			},
+		},
+		"NestedPathExists": {
+			reason: "Should return true when nested path exists",
+			args: args{
+				schema: &extv1.JSONSchemaProps{
+					Properties: map[string]extv1.JSONSchemaProps{
+						FieldSpec: {
+							Properties: map[string]extv1.JSONSchemaProps{
+								FieldForProvider: {
+									Properties: map[string]extv1.JSONSchemaProps{
+										FieldTags: {Type: "object"},
+									},
+								},
+							},
+						},
+					},
+				},
+				path: []string{FieldSpec, FieldForProvider, FieldTags},
+			},
+			want: want{
+				result: true,
+			},
+		},
+		"NestedPathPartiallyExists": {
+			reason: "Should return false when nested path partially exists",
+			args: args{
+				schema: &extv1.JSONSchemaProps{
+					Properties: map[string]extv1.JSONSchemaProps{
+						FieldSpec: {
+							Properties: map[string]extv1.JSONSchemaProps{
+								FieldForProvider: {},
+							},
+						},
+					},
+				},
+				path: []string{FieldSpec, FieldForProvider, FieldTags},
+			},
+			want: want{
+				result: false,
+			},
+		},
+		"NestedPathDoesNotExistAtFirstLevel": {
+			reason: "Should return false when first level of nested path does not exist",
+			args: args{
+				schema: &extv1.JSONSchemaProps{
+					Properties: map[string]extv1.JSONSchemaProps{
+						"status": {},
+					},
+				},
+				path: []string{FieldSpec, FieldForProvider, FieldTags},
+			},
+			want: want{
+				result: false,
+			},
+		},
+		"NestedPathDoesNotExistAtMiddleLevel": {
+			reason: "Should return false when middle level of nested path does not exist",
+			args: args{
+				schema: &extv1.JSONSchemaProps{
+					Properties: map[string]extv1.JSONSchemaProps{
+						FieldSpec: {
+							Properties: map[string]extv1.JSONSchemaProps{
+								"initProvider": {},
+							},
+						},
+					},
+				},
+				path: []string{FieldSpec, FieldForProvider, FieldTags},
+			},
+			want: want{
+				result: false,
+			},
+		},
+		"DeepNestedPath": {
+			reason: "Should handle deep nested paths correctly",
+			args: args{
+				schema: &extv1.JSONSchemaProps{
+					Properties: map[string]extv1.JSONSchemaProps{
+						FieldSpec: {
+							Properties: map[string]extv1.JSONSchemaProps{
+								FieldForProvider: {
+									Properties: map[string]extv1.JSONSchemaProps{
+										"config": {
+											Properties: map[string]extv1.JSONSchemaProps{
+												"settings": {
+													Properties: map[string]extv1.JSONSchemaProps{
+														FieldTags: {Type: "object"},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				path: []string{FieldSpec, FieldForProvider, "config", "settings", FieldTags},
+			},
+			want: want{
+				result: true,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := CheckFieldPath(tc.args.schema, tc.args.path)
+
+			if got != tc.want.result {
+				t.Errorf("%s\nCheckFieldPath(): want %v, got %v", tc.reason, tc.want.result, got)
+			}
+		})
+	}
+}
+
+func TestExaminePaths(t *testing.T) {
+	forProvider := func(properties map[string]extv1.JSONSchemaProps) *extv1.JSONSchemaProps {
+		return &extv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				FieldSpec: {Type: "object", Properties: map[string]extv1.JSONSchemaProps{
+					FieldForProvider: {Type: "object", Properties: properties},
+				}},
+			},
+		}
+	}
+
+	keyValueList := extv1.JSONSchemaProps{
+		Type: "array",
+		Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				FieldKey:   {Type: "string"},
+				FieldValue: {Type: "string"},
+			},
+		}},
+	}
+
+	stringMap := extv1.JSONSchemaProps{
+		Type:                 "object",
+		AdditionalProperties: &extv1.JSONSchemaPropsOrBool{Schema: &extv1.JSONSchemaProps{Type: "string"}},
+	}
+
+	type args struct {
+		schema *extv1.JSONSchemaProps
+		paths  []string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   Verdict
+	}{
+		"DefaultPath": {
+			reason: "Kinds that keep tags in a map at spec.forProvider.tags have no tag paths",
+			args: args{
+				schema: forProvider(map[string]extv1.JSONSchemaProps{FieldTags: {Type: "object"}}),
+				paths:  []string{"spec.forProvider.tags"},
+			},
+			want: Verdict{Enabled: true},
+		},
+		"OtherPath": {
+			reason: "Kinds that keep tags in a map at another path get its tag path",
+			args: args{
+				schema: forProvider(map[string]extv1.JSONSchemaProps{FieldLabels: {Type: "object"}}),
+				paths:  []string{"spec.forProvider.tags", "spec.forProvider.labels"},
+			},
+			want: Verdict{Enabled: true, TagPaths: []filters.TagPath{
+				{Desired: "spec.forProvider.labels", Observed: "status.atProvider.labels", Shape: filters.TagShapeMap},
+			}},
+		},
+		"MapBeforeList": {
+			reason: "Maps at any path are probed before lists of key/value objects",
+			args: args{
+				schema: forProvider(map[string]extv1.JSONSchemaProps{FieldTag: keyValueList, FieldLabels: {Type: "object"}}),
+				paths:  []string{"spec.forProvider.tags", "spec.forProvider.labels"},
+			},
+			want: Verdict{Enabled: true, TagPaths: []filters.TagPath{
+				{Desired: "spec.forProvider.labels", Observed: "status.atProvider.labels", Shape: filters.TagShapeMap},
+			}},
+		},
+		"KeyValueList": {
+			reason: "A list of key/value objects at the tag field of a path, or its list tag field, holds tags",
+			args: args{
+				schema: forProvider(map[string]extv1.JSONSchemaProps{FieldTag: keyValueList}),
+				paths:  []string{"spec.forProvider.tags"},
+			},
+			want: Verdict{Enabled: true, TagPaths: []filters.TagPath{
+				{Desired: "spec.forProvider.tag", Observed: "status.atProvider.tag", Shape: filters.TagShapeKeyValueList},
+			}},
+		},
+		"OtherTagPaths": {
+			reason: "Other tag maps of spec.forProvider, and of its objects and single-item arrays, follow the primary tag path",
+			args: args{
+				schema: forProvider(map[string]extv1.JSONSchemaProps{
+					FieldTags:             stringMap,
+					"volumeTags":          stringMap,
+					"propagateTags":       {Type: "string"},
+					"rootBlockDevice":     {Type: "array", MaxItems: ptr.To[int64](1), Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{Type: "object", Properties: map[string]extv1.JSONSchemaProps{FieldTags: stringMap}}}},
+					"ebsBlockDevice":      {Type: "array", Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{Type: "object", Properties: map[string]extv1.JSONSchemaProps{FieldTags: stringMap}}}},
+					"capacityReservation": {Type: "object", Properties: map[string]extv1.JSONSchemaProps{FieldTags: stringMap}},
+				}),
+				paths: []string{"spec.forProvider.tags"},
+			},
+			want: Verdict{Enabled: true, TagPaths: []filters.TagPath{
+				{Desired: "spec.forProvider.tags", Observed: "status.atProvider.tags", Shape: filters.TagShapeMap},
+				{Desired: "spec.forProvider.capacityReservation.tags", Observed: "status.atProvider.capacityReservation.tags", Shape: filters.TagShapeMap},
+				{Desired: "spec.forProvider.rootBlockDevice[0].tags", Observed: "status.atProvider.rootBlockDevice[0].tags", Shape: filters.TagShapeMap},
+				{Desired: "spec.forProvider.volumeTags", Observed: "status.atProvider.volumeTags", Shape: filters.TagShapeMap},
+			}},
+		},
+		"InvalidPath": {
+			reason: "Paths that are not valid tag field paths are skipped",
+			args: args{
+				schema: forProvider(map[string]extv1.JSONSchemaProps{FieldTags: {Type: "object"}}),
+				paths:  []string{"spec.initProvider.tags"},
+			},
+			want: Verdict{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, ExaminePaths(tc.args.schema, tc.args.paths)); diff != "" {
+				t.Errorf("%s\nExaminePaths(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestParseTagFieldPath(t *testing.T) {
+	type want struct {
+		fields []string
+		err    bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		path   string
+		want   want
+	}{
+		"ForProviderField": {
+			reason: "A field of spec.forProvider is a tag field path",
+			path:   "spec.forProvider.labels",
+			want:   want{fields: []string{FieldSpec, FieldForProvider, FieldLabels}},
+		},
+		"NestedField": {
+			reason: "A field of an object of spec.forProvider is a tag field path",
+			path:   "spec.forProvider.metadata[\"labels\"]",
+			want:   want{fields: []string{FieldSpec, FieldForProvider, "metadata", FieldLabels}},
+		},
+		"Malformed": {
+			reason: "A path that cannot be parsed is an error",
+			path:   "spec.forProvider.tags[",
+			want:   want{err: true},
+		},
+		"ArrayIndex": {
+			reason: "A path that indexes an array is an error",
+			path:   "spec.forProvider.rootBlockDevice[0].tags",
+			want:   want{err: true},
+		},
+		"OutsideForProvider": {
+			reason: "A path outside spec.forProvider is an error",
+			path:   "spec.initProvider.tags",
+			want:   want{err: true},
+		},
+		"ForProvider": {
+			reason: "spec.forProvider itself is not a tag field path",
+			path:   "spec.forProvider",
+			want:   want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fields, err := ParseTagFieldPath(tc.path)
+
+			if (err != nil) != tc.want.err {
+				t.Fatalf("%s\nParseTagFieldPath(%q): want error %t, got %v", tc.reason, tc.path, tc.want.err, err)
+			}
+
+			if diff := cmp.Diff(tc.want.fields, fields); diff != "" {
+				t.Errorf("%s\nParseTagFieldPath(%q): -want, +got:\n%s", tc.reason, tc.path, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package crd
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// InventoryFields returns the names of the fields that FindTagFields
+// records: tags, labels, and the tag fields with their list tag fields.
+func InventoryFields(tagFields ...string) []string {
+	names := []string{FieldTags, FieldLabels}
+
+	for _, tagField := range tagFields {
+		for _, f := range append([]string{tagField}, ListTagFields(tagField)...) {
+			if !slices.Contains(names, f) {
+				names = append(names, f)
+			}
+		}
+	}
+
+	return names
+}
+
+// FindTagFields walks the spec of a schema and returns every field with one
+// of the names that holds a map of strings or a list of key/value objects,
+// including fields in objects of arrays. The fields are sorted by path.
+func FindTagFields(schema *extv1.JSONSchemaProps, names []string) []filters.TagField {
+	found := make([]filters.TagField, 0)
+
+	spec := GetFieldPath(schema, []string{FieldSpec})
+	if spec == nil {
+		return found
+	}
+
+	walkTagFields(spec, FieldSpec, names, &found)
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Path < found[j].Path
+	})
+
+	return found
+}
+
+func walkTagFields(schema *extv1.JSONSchemaProps, path string, names []string, found *[]filters.TagField) {
+	for name, property := range schema.Properties {
+		p := path + "." + name
+
+		if slices.Contains(names, name) {
+			switch {
+			case IsStringMap(&property):
+				*found = append(*found, filters.TagField{Path: p, Shape: filters.TagShapeMap})
+				continue
+			case IsKeyValueList(&property):
+				*found = append(*found, filters.TagField{Path: p, Shape: filters.TagShapeKeyValueList})
+				continue
+			}
+		}
+
+		switch property.Type {
+		case "object":
+			walkTagFields(&property, p, names, found)
+		case "array":
+			if property.Items != nil && property.Items.Schema != nil {
+				walkTagFields(property.Items.Schema, p+filters.ArrayMarker, names, found)
+			}
+		}
+	}
+}
+
+// IsStringMap returns true if a schema is an object of string values.
+func IsStringMap(property *extv1.JSONSchemaProps) bool {
+	if property == nil || property.Type != "object" || property.AdditionalProperties == nil {
+		return false
+	}
+
+	values := property.AdditionalProperties.Schema
+
+	return values != nil && values.Type == "string"
+}
@@ -0,0 +1,139 @@
+package crd
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestFindTagFields(t *testing.T) {
+	stringMap := extv1.JSONSchemaProps{
+		Type:                 "object",
+		AdditionalProperties: &extv1.JSONSchemaPropsOrBool{Allows: true, Schema: &extv1.JSONSchemaProps{Type: "string"}},
+	}
+
+	keyValueList := extv1.JSONSchemaProps{
+		Type: "array",
+		Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				FieldKey:   {Type: "string"},
+				FieldValue: {Type: "string"},
+			},
+		}},
+	}
+
+	object := func(properties map[string]extv1.JSONSchemaProps) extv1.JSONSchemaProps {
+		return extv1.JSONSchemaProps{Type: "object", Properties: properties}
+	}
+
+	array := func(item extv1.JSONSchemaProps) extv1.JSONSchemaProps {
+		return extv1.JSONSchemaProps{Type: "array", Items: &extv1.JSONSchemaPropsOrArray{Schema: &item}}
+	}
+
+	type args struct {
+		schema *extv1.JSONSchemaProps
+		names  []string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []filters.TagField
+	}{
+		"NoSpec": {
+			reason: "A schema without spec has no tag fields",
+			args: args{
+				schema: &extv1.JSONSchemaProps{Type: "object"},
+				names:  InventoryFields(FieldTags),
+			},
+			want: []filters.TagField{},
+		},
+		"NestedFields": {
+			reason: "Tag maps and key/value lists are found in objects and arrays of the spec",
+			args: args{
+				schema: &extv1.JSONSchemaProps{Properties: map[string]extv1.JSONSchemaProps{
+					FieldSpec: object(map[string]extv1.JSONSchemaProps{
+						FieldForProvider: object(map[string]extv1.JSONSchemaProps{
+							FieldTags:    stringMap,
+							"volumeTags": stringMap,
+							"launchTemplate": array(object(map[string]extv1.JSONSchemaProps{
+								"tagSpecifications": array(object(map[string]extv1.JSONSchemaProps{
+									FieldTags: stringMap,
+								})),
+							})),
+							"autoscaling": object(map[string]extv1.JSONSchemaProps{
+								FieldTag: keyValueList,
+							}),
+						}),
+						"initProvider": object(map[string]extv1.JSONSchemaProps{
+							FieldTags: stringMap,
+						}),
+					}),
+					FieldStatus: object(map[string]extv1.JSONSchemaProps{
+						FieldAtProvider: object(map[string]extv1.JSONSchemaProps{
+							FieldTags: stringMap,
+						}),
+					}),
+				}},
+				names: InventoryFields(FieldTags),
+			},
+			want: []filters.TagField{
+				{Path: "spec.forProvider.autoscaling.tag", Shape: filters.TagShapeKeyValueList},
+				{Path: "spec.forProvider.launchTemplate[].tagSpecifications[].tags", Shape: filters.TagShapeMap},
+				{Path: "spec.forProvider.tags", Shape: filters.TagShapeMap},
+				{Path: "spec.initProvider.tags", Shape: filters.TagShapeMap},
+			},
+		},
+		"OtherShapes": {
+			reason: "Fields named like tags that are not a map of strings or a list of key/value objects are not tag fields",
+			args: args{
+				schema: &extv1.JSONSchemaProps{Properties: map[string]extv1.JSONSchemaProps{
+					FieldSpec: object(map[string]extv1.JSONSchemaProps{
+						FieldForProvider: object(map[string]extv1.JSONSchemaProps{
+							FieldTags:   {Type: "string"},
+							FieldLabels: object(map[string]extv1.JSONSchemaProps{"team": {Type: "string"}}),
+							"selector": object(map[string]extv1.JSONSchemaProps{
+								"matchLabels": stringMap,
+							}),
+						}),
+					}),
+				}},
+				names: InventoryFields(FieldTags),
+			},
+			want: []filters.TagField{},
+		},
+		"Labels": {
+			reason: "Labels are tag fields",
+			args: args{
+				schema: &extv1.JSONSchemaProps{Properties: map[string]extv1.JSONSchemaProps{
+					FieldSpec: object(map[string]extv1.JSONSchemaProps{
+						FieldForProvider: object(map[string]extv1.JSONSchemaProps{
+							FieldLabels: stringMap,
+							"nodeConfig": array(object(map[string]extv1.JSONSchemaProps{
+								"resourceLabels": stringMap,
+								FieldLabels:      stringMap,
+							})),
+						}),
+					}),
+				}},
+				names: InventoryFields(FieldLabels),
+			},
+			want: []filters.TagField{
+				{Path: "spec.forProvider.labels", Shape: filters.TagShapeMap},
+				{Path: "spec.forProvider.nodeConfig[].labels", Shape: filters.TagShapeMap},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := FindTagFields(tc.args.schema, tc.args.names)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nFindTagFields(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package filters
+
+// NewCustomResourceFilter returns entries that are maintained by hand
+// instead of generated, like patterns that declare the API groups of
+// in-house providers as taggable:
+//
+//	"*.platform.example.com/*": true,
+//
+// They are copied after the generated entries, so they replace generated
+// entries with the same key.
+func NewCustomResourceFilter() ResourceFilter {
+	return ResourceFilter{
+		// AutoscalingGroups keep tags in a list of key/value objects, which
+		// the scan of the checked-in AWS filter did not detect
+		"autoscaling.aws.upbound.io/AutoscalingGroup":   true,
+		"autoscaling.aws.m.upbound.io/AutoscalingGroup": true,
+	}
+}
+
+// NewCustomTagSupportVersions returns version ranges that are maintained by
+// hand instead of generated, like the kinds the v0.9.0 release notes list as
+// taggable from provider v2.7.0. Generated ranges of the same kind replace
+// them.
+func NewCustomTagSupportVersions() TagSupportVersions {
+	return TagSupportVersions{
+		"cloudfront.aws.upbound.io/Function":                       {Provider: "provider-upjet-aws", MinVersion: "v2.7.0"},
+		"cloudfront.aws.m.upbound.io/Function":                     {Provider: "provider-upjet-aws", MinVersion: "v2.7.0"},
+		"ec2.aws.upbound.io/VPCIpamPoolCidrAllocation":             {Provider: "provider-upjet-aws", MinVersion: "v2.7.0"},
+		"ec2.aws.m.upbound.io/VPCIpamPoolCidrAllocation":           {Provider: "provider-upjet-aws", MinVersion: "v2.7.0"},
+		"cognitiveservices.azure.upbound.io/AccountRaiBlocklist":   {Provider: "provider-upjet-azure", MinVersion: "v2.7.0"},
+		"cognitiveservices.azure.m.upbound.io/AccountRaiBlocklist": {Provider: "provider-upjet-azure", MinVersion: "v2.7.0"},
+	}
+}
+
+// awsInstanceTagPaths tag EC2 Instances and their root volumes. The root
+// block device is a list in v1beta1 and an object in later versions.
+var awsInstanceTagPaths = []TagPath{
+	DefaultTagPath,
+	{Desired: "spec.forProvider.rootBlockDevice[0].tags", Observed: "status.atProvider.rootBlockDevice[0].tags"},
+	{Desired: "spec.forProvider.rootBlockDevice.tags", Observed: "status.atProvider.rootBlockDevice.tags"},
+}
+
+// awsAutoscalingGroupTagPaths tag AutoscalingGroups through their list of
+// tag blocks.
+var awsAutoscalingGroupTagPaths = []TagPath{
+	{Desired: "spec.forProvider.tag", Observed: "status.atProvider.tag", Shape: TagShapeKeyValueList},
+}
+
+// customTagPaths are the tag paths of kinds that are maintained by hand
+// until the generated tag paths include them. Generated tag paths of the
+// same kind replace them.
+var customTagPaths = TagPaths{
+	"ec2.aws.upbound.io/Instance":                   awsInstanceTagPaths,
+	"ec2.aws.m.upbound.io/Instance":                 awsInstanceTagPaths,
+	"autoscaling.aws.upbound.io/AutoscalingGroup":   awsAutoscalingGroupTagPaths,
+	"autoscaling.aws.m.upbound.io/AutoscalingGroup": awsAutoscalingGroupTagPaths,
+}
+
+// customProviderTagPaths maps the group suffix of a provider family that is
+// not generated yet to the tag path of its kinds. Generated provider tag
+// paths take precedence.
+var customProviderTagPaths = map[string]TagPath{
+	"gcp.upbound.io": {
+		Desired:  "spec.forProvider.labels",
+		Observed: "status.atProvider.labels",
+	},
+}
@@ -1,17 +1,118 @@
 // Package filters determines whether resources support tags
 package filters
 
-import "maps"
+import (
+	"path"
+	"slices"
+	"strings"
 
-// ResourceFilter is a map indicating whether a resource supports tags.
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+)
+
+// patternCharacters are the characters that make a ResourceFilter key a
+// pattern instead of an exact group/Kind.
+const patternCharacters = `*?[\`
+
+// ResourceFilter matches group/Kinds that support tags. Keys are exact
+// group/Kinds, like ec2.aws.upbound.io/Instance, or patterns of
+// path.Match, like *.platform.example.com/*, that match whole API groups.
+//
+// An exact entry takes precedence over patterns, so an exact entry set to
+// false denies a kind that a pattern allows. When only patterns match, a
+// pattern set to false takes precedence over a pattern set to true.
+//
+// Keys can also be exact group/version/Kinds, like
+// ec2.aws.upbound.io/v1beta2/Instance, for API versions of a kind whose tag
+// support differs from its group/Kind entry.
 type ResourceFilter map[string]bool
 
-// NewResourceFilter returns a map of resources that support tags.
-// these values were generated by querying Provider CRDs for `spec.forProvider.tags` support.
-func NewResourceFilter() ResourceFilter {
-	all := make(ResourceFilter)
-	maps.Copy(all, NewAWSResourceFilter())
-	maps.Copy(all, NewAzureResourceFilter())
+// GroupKindKey returns the ResourceFilter key of a kind, like
+// ec2.aws.upbound.io/Instance.
+func GroupKindKey(gvk schema.GroupVersionKind) string {
+	return gvk.Group + "/" + gvk.Kind
+}
+
+// GroupVersionKindKey returns the ResourceFilter key of an API version of a
+// kind, like ec2.aws.upbound.io/v1beta2/Instance.
+func GroupVersionKindKey(gvk schema.GroupVersionKind) string {
+	return gvk.Group + "/" + gvk.Version + "/" + gvk.Kind
+}
+
+// SupportsGVK returns true if an API version of a kind supports tags.
+func (f ResourceFilter) SupportsGVK(gvk schema.GroupVersionKind) bool {
+	supported, _ := f.MatchGVK(gvk)
+	return supported
+}
+
+// MatchGVK returns whether an API version of a kind supports tags, and
+// whether an entry matched it. An exact group/version/Kind entry takes
+// precedence over the entries that match its group/Kind.
+func (f ResourceFilter) MatchGVK(gvk schema.GroupVersionKind) (supported, matched bool) {
+	if v, ok := f[GroupVersionKindKey(gvk)]; ok {
+		return v, true
+	}
+
+	return f.Match(GroupKindKey(gvk))
+}
+
+// Supports returns true if a group/Kind supports tags. Kinds that match no
+// entry do not support tags.
+func (f ResourceFilter) Supports(groupKind string) bool {
+	supported, _ := f.Match(groupKind)
+	return supported
+}
+
+// Match returns whether a group/Kind supports tags, and whether an exact
+// entry or a pattern matched it.
+func (f ResourceFilter) Match(groupKind string) (supported, matched bool) {
+	if v, ok := f[groupKind]; ok {
+		return v, true
+	}
+
+	for _, p := range f.Patterns() {
+		if ok, _ := path.Match(p, groupKind); !ok {
+			continue
+		}
+
+		if !f[p] {
+			return false, true
+		}
+
+		matched = true
+	}
+
+	return matched, matched
+}
+
+// Patterns returns the sorted keys of the filter that are patterns.
+func (f ResourceFilter) Patterns() []string {
+	patterns := make([]string, 0)
+
+	for k := range f {
+		if IsPattern(k) {
+			patterns = append(patterns, k)
+		}
+	}
+
+	slices.Sort(patterns)
+
+	return patterns
+}
+
+// Validate returns an error if a pattern of the filter is malformed.
+func (f ResourceFilter) Validate() error {
+	for _, p := range f.Patterns() {
+		if _, err := path.Match(p, ""); err != nil {
+			return errors.Wrapf(err, "invalid resource filter pattern %q", p)
+		}
+	}
+
+	return nil
+}
 
-	return all
+// IsPattern returns true if a ResourceFilter key is a pattern.
+func IsPattern(key string) bool {
+	return strings.ContainsAny(key, patternCharacters)
 }
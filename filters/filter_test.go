@@ -0,0 +1,141 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestResourceFilterMatch(t *testing.T) {
+	filter := ResourceFilter{
+		"ec2.aws.upbound.io/VPC":            true,
+		"db.platform.example.com/Secret":    false,
+		"cache.platform.example.com/Policy": true,
+		"*.platform.example.com/*":          true,
+		"*.platform.example.com/Policy":     false,
+	}
+
+	type want struct {
+		supported bool
+		matched   bool
+	}
+
+	cases := map[string]struct {
+		reason    string
+		groupKind string
+		want      want
+	}{
+		"ExactAllow": {
+			reason:    "An exact entry set to true supports tags",
+			groupKind: "ec2.aws.upbound.io/VPC",
+			want:      want{supported: true, matched: true},
+		},
+		"ExactDeny": {
+			reason:    "An exact entry set to false takes precedence over an allow pattern",
+			groupKind: "db.platform.example.com/Secret",
+			want:      want{supported: false, matched: true},
+		},
+		"ExactAllowOverPatternDeny": {
+			reason:    "An exact entry set to true takes precedence over a deny pattern",
+			groupKind: "cache.platform.example.com/Policy",
+			want:      want{supported: true, matched: true},
+		},
+		"PatternAllow": {
+			reason:    "Kinds without an exact entry support tags if an allow pattern matches",
+			groupKind: "db.platform.example.com/Database",
+			want:      want{supported: true, matched: true},
+		},
+		"PatternDeny": {
+			reason:    "A deny pattern takes precedence over an allow pattern",
+			groupKind: "db.platform.example.com/Policy",
+			want:      want{supported: false, matched: true},
+		},
+		"NoMatch": {
+			reason:    "Kinds that match no entry do not support tags",
+			groupKind: "s3.aws.upbound.io/Bucket",
+			want:      want{supported: false, matched: false},
+		},
+		"PatternWithinGroup": {
+			reason:    "A pattern does not match across the group/Kind separator",
+			groupKind: "platform.example.com/Database",
+			want:      want{supported: false, matched: false},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			supported, matched := filter.Match(tc.groupKind)
+
+			if diff := cmp.Diff(tc.want, want{supported: supported, matched: matched}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("%s\nMatch(%q): -want, +got:\n%s", tc.reason, tc.groupKind, diff)
+			}
+		})
+	}
+}
+
+func TestResourceFilterMatchGVK(t *testing.T) {
+	filter := ResourceFilter{
+		"ec2.aws.upbound.io/Instance":         true,
+		"ec2.aws.upbound.io/v1beta1/Instance": false,
+		"*.platform.example.com/*":            true,
+	}
+
+	type want struct {
+		supported bool
+		matched   bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		gvk    schema.GroupVersionKind
+		want   want
+	}{
+		"VersionEntry": {
+			reason: "An exact group/version/Kind entry takes precedence over the group/Kind entry",
+			gvk:    schema.GroupVersionKind{Group: "ec2.aws.upbound.io", Version: "v1beta1", Kind: "Instance"},
+			want:   want{supported: false, matched: true},
+		},
+		"GroupKindFallback": {
+			reason: "Versions without an entry fall back to the group/Kind entry",
+			gvk:    schema.GroupVersionKind{Group: "ec2.aws.upbound.io", Version: "v1beta2", Kind: "Instance"},
+			want:   want{supported: true, matched: true},
+		},
+		"PatternFallback": {
+			reason: "Versions without an exact entry fall back to patterns of their group/Kind",
+			gvk:    schema.GroupVersionKind{Group: "db.platform.example.com", Version: "v1alpha1", Kind: "Database"},
+			want:   want{supported: true, matched: true},
+		},
+		"NoMatch": {
+			reason: "Kinds that match no entry do not support tags",
+			gvk:    schema.GroupVersionKind{Group: "s3.aws.upbound.io", Version: "v1beta1", Kind: "Bucket"},
+			want:   want{supported: false, matched: false},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			supported, matched := filter.MatchGVK(tc.gvk)
+
+			if diff := cmp.Diff(tc.want, want{supported: supported, matched: matched}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("%s\nMatchGVK(%v): -want, +got:\n%s", tc.reason, tc.gvk, diff)
+			}
+		})
+	}
+}
+
+func TestResourceFilterValidate(t *testing.T) {
+	if err := (ResourceFilter{"*.platform.example.com/*": true}).Validate(); err != nil {
+		t.Errorf("Validate(...): want no error, got %v", err)
+	}
+
+	if err := (ResourceFilter{"[.platform.example.com/*": true}).Validate(); err == nil {
+		t.Errorf("Validate(...): want error for malformed pattern, got nil")
+	}
+}
+
+func TestNewResourceFilterValid(t *testing.T) {
+	if err := NewResourceFilter().Validate(); err != nil {
+		t.Errorf("NewResourceFilter().Validate(): want no error, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package filters
+
+import (
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/fieldpath"
+)
+
+// ArrayMarker marks the fields of a TagField path that are arrays, like
+// spec.forProvider.rootBlockDevice[].tags.
+const ArrayMarker = "[]"
+
+// TagField is a field of the spec of a kind that holds tags.
+type TagField struct {
+	// Path of the field. Arrays on the path are marked with ArrayMarker.
+	Path string
+	// Shape of the tags.
+	Shape TagShape
+}
+
+// TagFieldInventory maps a group/Kind to every field of its spec that holds
+// tags or labels, like spec.forProvider.tags, spec.initProvider.tags and
+// spec.forProvider.rootBlockDevice[].tags.
+type TagFieldInventory map[string][]TagField
+
+// TagPath returns the TagPath of a field of spec.forProvider, whose observed
+// field is in status.atProvider. It returns false for other fields and for
+// fields in arrays, which cannot be addressed by a single field path.
+func (f TagField) TagPath() (TagPath, bool) {
+	const desired, observed = "spec.forProvider.", "status.atProvider."
+
+	if !strings.HasPrefix(f.Path, desired) || strings.Contains(f.Path, ArrayMarker) {
+		return TagPath{}, false
+	}
+
+	if _, err := fieldpath.Parse(f.Path); err != nil {
+		return TagPath{}, false
+	}
+
+	return TagPath{
+		Desired:  f.Path,
+		Observed: observed + strings.TrimPrefix(f.Path, desired),
+		Shape:    f.Shape,
+	}, true
+}
@@ -0,0 +1,46 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTagFieldTagPath(t *testing.T) {
+	type want struct {
+		path TagPath
+		ok   bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		field  TagField
+		want   want
+	}{
+		"ForProvider": {
+			reason: "A field of spec.forProvider is observed in status.atProvider",
+			field:  TagField{Path: "spec.forProvider.volumeTags", Shape: TagShapeMap},
+			want: want{
+				path: TagPath{Desired: "spec.forProvider.volumeTags", Observed: "status.atProvider.volumeTags", Shape: TagShapeMap},
+				ok:   true,
+			},
+		},
+		"InitProvider": {
+			reason: "Fields outside spec.forProvider have no TagPath",
+			field:  TagField{Path: "spec.initProvider.tags", Shape: TagShapeMap},
+		},
+		"Array": {
+			reason: "Fields in arrays cannot be addressed by a single field path",
+			field:  TagField{Path: "spec.forProvider.rootBlockDevice[].tags", Shape: TagShapeMap},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			path, ok := tc.field.TagPath()
+			if diff := cmp.Diff(tc.want, want{path: path, ok: ok}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("%s\nTagPath(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package filters
+
+import "strings"
+
+// TagLimits are the constraints a cloud provider places on tags.
+// A zero value means there is no limit.
+type TagLimits struct {
+	// Provider is the name of the provider family, like "aws".
+	Provider string
+	// MaxKeyLength is the maximum number of characters in a key.
+	MaxKeyLength int
+	// MaxValueLength is the maximum number of characters in a value.
+	MaxValueLength int
+	// MaxTags is the maximum number of tags on a resource.
+	MaxTags int
+	// ReservedPrefixes are key prefixes that cannot be used. They are
+	// matched case-insensitively.
+	ReservedPrefixes []string
+	// InvalidKeyCharacters are characters that cannot be used in a key.
+	InvalidKeyCharacters string
+	// Normalize fixes tags to the provider's rules, even if tags are not
+	// sanitized. Keys and values are lowercased, characters that are not in
+	// ValidCharacters are replaced with an underscore and keys and values are
+	// truncated to the maximum length. Keys must start with a letter.
+	Normalize bool
+	// ValidCharacters are the only characters allowed in normalized keys
+	// and values.
+	ValidCharacters string
+}
+
+// providerTagLimits maps the group suffix of a provider family to its limits.
+var providerTagLimits = map[string]TagLimits{
+	"aws.upbound.io": {
+		Provider:         "aws",
+		MaxKeyLength:     128,
+		MaxValueLength:   256,
+		MaxTags:          50,
+		ReservedPrefixes: []string{"aws:"},
+	},
+	"azure.upbound.io": {
+		Provider:             "azure",
+		MaxKeyLength:         512,
+		MaxValueLength:       256,
+		MaxTags:              50,
+		InvalidKeyCharacters: `<>%&\?/`,
+	},
+	"gcp.upbound.io": {
+		Provider:        "gcp",
+		MaxKeyLength:    63,
+		MaxValueLength:  63,
+		MaxTags:         64,
+		Normalize:       true,
+		ValidCharacters: "abcdefghijklmnopqrstuvwxyz0123456789_-",
+	},
+}
+
+// TagLimitsForGroup returns the tag limits of the provider family that
+// serves an API group, like ec2.aws.upbound.io or ec2.aws.m.upbound.io.
+func TagLimitsForGroup(group string) (TagLimits, bool) {
+	for suffix, limits := range providerTagLimits {
+		if providerGroup(group, suffix) {
+			return limits, true
+		}
+	}
+
+	return TagLimits{}, false
+}
+
+// providerGroup returns true if the group belongs to the cluster-scoped
+// (<service>.<suffix>) or namespaced (<service>.<provider>.m.<domain>)
+// API groups of a provider family.
+func providerGroup(group, suffix string) bool {
+	if strings.HasSuffix(group, "."+suffix) {
+		return true
+	}
+
+	provider, domain, ok := strings.Cut(suffix, ".")
+	if !ok {
+		return false
+	}
+
+	return strings.HasSuffix(group, "."+provider+".m."+domain)
+}
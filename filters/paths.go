@@ -0,0 +1,133 @@
+package filters
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TagShape is how a resource stores its tags.
+type TagShape string
+
+const (
+	// TagShapeMap stores tags as a map of keys to values.
+	TagShapeMap TagShape = "Map"
+	// TagShapeKeyValueList stores tags as a list of objects with key and
+	// value fields, like the tag blocks of AWS AutoscalingGroups.
+	TagShapeKeyValueList TagShape = "KeyValueList"
+)
+
+// TagPath is a pair of field paths where a resource keeps tags.
+type TagPath struct {
+	// Desired is the field path of the tags in the desired resource.
+	Desired string
+	// Observed is the field path of the tags in the observed resource.
+	Observed string
+	// Shape of the tags. An empty shape is a TagShapeMap.
+	Shape TagShape
+	// Defaults are the values of extra fields of new entries in a
+	// TagShapeKeyValueList, like propagateAtLaunch.
+	Defaults map[string]any
+}
+
+// GetShape returns the shape of the tags.
+func (p TagPath) GetShape() TagShape {
+	if p.Shape == "" {
+		return TagShapeMap
+	}
+
+	return p.Shape
+}
+
+// DefaultTagPath is where most managed resources keep their tags.
+var DefaultTagPath = TagPath{
+	Desired:  "spec.forProvider.tags",
+	Observed: "status.atProvider.tags",
+}
+
+// TagPaths maps a group/Kind to the field paths of its tags. The first path
+// is the primary tags of the resource. Keys can also be group/version/Kinds,
+// for API versions of a kind whose tag paths differ from its group/Kind
+// entry.
+type TagPaths map[string][]TagPath
+
+// keyValueListDefaults are the values of extra fields of new tag entries
+// that the provider requires.
+var keyValueListDefaults = map[string]map[string]any{
+	"autoscaling.aws.upbound.io/AutoscalingGroup":   {"propagateAtLaunch": true},
+	"autoscaling.aws.m.upbound.io/AutoscalingGroup": {"propagateAtLaunch": true},
+}
+
+// NewTagPaths returns the field paths of resources that keep tags in
+// more than the default path, or in a different shape.
+func NewTagPaths() TagPaths {
+	all := newProviderTagPaths()
+
+	for gk, paths := range customTagPaths {
+		if _, ok := all[gk]; !ok {
+			all[gk] = paths
+		}
+	}
+
+	for key, paths := range all {
+		defaults, ok := keyValueListDefaults[keyGroupKind(key)]
+		if !ok {
+			continue
+		}
+
+		withDefaults := make([]TagPath, 0, len(paths))
+
+		for _, p := range paths {
+			if p.GetShape() == TagShapeKeyValueList {
+				p.Defaults = defaults
+			}
+
+			withDefaults = append(withDefaults, p)
+		}
+
+		all[key] = withDefaults
+	}
+
+	return all
+}
+
+// GetGVK returns the tag paths of an API version of a kind. An exact
+// group/version/Kind entry takes precedence over the tag paths of its
+// group/Kind.
+func (t TagPaths) GetGVK(gvk schema.GroupVersionKind) []TagPath {
+	if paths, ok := t[GroupVersionKindKey(gvk)]; ok && len(paths) > 0 {
+		return paths
+	}
+
+	return t.Get(GroupKindKey(gvk))
+}
+
+// Get returns the tag paths of a group/Kind. Kinds without an entry use
+// the tag path of their provider family, or the DefaultTagPath.
+func (t TagPaths) Get(groupKind string) []TagPath {
+	if paths, ok := t[groupKind]; ok && len(paths) > 0 {
+		return paths
+	}
+
+	group, _, _ := strings.Cut(groupKind, "/")
+	for _, tp := range []map[string]TagPath{providerTagPaths, customProviderTagPaths} {
+		for suffix, p := range tp {
+			if providerGroup(group, suffix) {
+				return []TagPath{p}
+			}
+		}
+	}
+
+	return []TagPath{DefaultTagPath}
+}
+
+// keyGroupKind returns the group/Kind of a group/Kind or group/version/Kind
+// key.
+func keyGroupKind(key string) string {
+	group, rest, _ := strings.Cut(key, "/")
+	if _, kind, ok := strings.Cut(rest, "/"); ok {
+		return group + "/" + kind
+	}
+
+	return key
+}
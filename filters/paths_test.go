@@ -0,0 +1,111 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestTagPathsGetGVK(t *testing.T) {
+	tagList := TagPath{Desired: "spec.forProvider.tag", Observed: "status.atProvider.tag", Shape: TagShapeKeyValueList}
+
+	paths := TagPaths{
+		"autoscaling.aws.upbound.io/AutoscalingGroup":         {tagList},
+		"autoscaling.aws.upbound.io/v1beta1/AutoscalingGroup": {DefaultTagPath},
+	}
+
+	cases := map[string]struct {
+		reason string
+		gvk    schema.GroupVersionKind
+		want   []TagPath
+	}{
+		"VersionEntry": {
+			reason: "An exact group/version/Kind entry takes precedence over the group/Kind entry",
+			gvk:    schema.GroupVersionKind{Group: "autoscaling.aws.upbound.io", Version: "v1beta1", Kind: "AutoscalingGroup"},
+			want:   []TagPath{DefaultTagPath},
+		},
+		"GroupKindFallback": {
+			reason: "Versions without an entry fall back to the group/Kind entry",
+			gvk:    schema.GroupVersionKind{Group: "autoscaling.aws.upbound.io", Version: "v1beta2", Kind: "AutoscalingGroup"},
+			want:   []TagPath{tagList},
+		},
+		"DefaultFallback": {
+			reason: "Kinds without an entry use the default tag path",
+			gvk:    schema.GroupVersionKind{Group: "s3.aws.upbound.io", Version: "v1beta1", Kind: "Bucket"},
+			want:   []TagPath{DefaultTagPath},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, paths.GetGVK(tc.gvk)); diff != "" {
+				t.Errorf("%s\nGetGVK(%v): -want, +got:\n%s", tc.reason, tc.gvk, diff)
+			}
+		})
+	}
+}
+
+func TestKeyGroupKind(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		key    string
+		want   string
+	}{
+		"GroupKind": {
+			reason: "A group/Kind key is its own group/Kind",
+			key:    "autoscaling.aws.upbound.io/AutoscalingGroup",
+			want:   "autoscaling.aws.upbound.io/AutoscalingGroup",
+		},
+		"GroupVersionKind": {
+			reason: "The version of a group/version/Kind key is dropped",
+			key:    "autoscaling.aws.upbound.io/v1beta1/AutoscalingGroup",
+			want:   "autoscaling.aws.upbound.io/AutoscalingGroup",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := keyGroupKind(tc.key); got != tc.want {
+				t.Errorf("%s\nkeyGroupKind(%q): want %q, got %q", tc.reason, tc.key, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNewTagPathsCustom(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		gvk    schema.GroupVersionKind
+		want   []TagPath
+	}{
+		"Instance": {
+			reason: "EC2 Instances tag their root volumes until the generated tag paths include them",
+			gvk:    schema.GroupVersionKind{Group: "ec2.aws.upbound.io", Version: "v1beta1", Kind: "Instance"},
+			want:   awsInstanceTagPaths,
+		},
+		"NamespacedInstance": {
+			reason: "Namespaced EC2 Instances tag their root volumes until the generated tag paths include them",
+			gvk:    schema.GroupVersionKind{Group: "ec2.aws.m.upbound.io", Version: "v1beta1", Kind: "Instance"},
+			want:   awsInstanceTagPaths,
+		},
+		"AutoscalingGroup": {
+			reason: "AutoscalingGroups keep tags in a list of key/value objects with the defaults of the provider",
+			gvk:    schema.GroupVersionKind{Group: "autoscaling.aws.upbound.io", Version: "v1beta1", Kind: "AutoscalingGroup"},
+			want: []TagPath{{
+				Desired:  "spec.forProvider.tag",
+				Observed: "status.atProvider.tag",
+				Shape:    TagShapeKeyValueList,
+				Defaults: map[string]any{"propagateAtLaunch": true},
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, NewTagPaths().GetGVK(tc.gvk)); diff != "" {
+				t.Errorf("%s\nGetGVK(%v): -want, +got:\n%s", tc.reason, tc.gvk, diff)
+			}
+		})
+	}
+}
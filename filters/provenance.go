@@ -0,0 +1,26 @@
+package filters
+
+// Provenance records where the generated filters of a provider came from.
+// Fields that were not known when the filters were generated are empty.
+type Provenance struct {
+	// Provider is the name of the provider, like provider-upjet-aws.
+	Provider string
+	// Source is the repository, directory or package the CRDs were read
+	// from.
+	Source string
+	// Ref is the Git reference that was scanned.
+	Ref string
+	// Version is the release tag of the scanned commit.
+	Version string
+	// Commit is the scanned commit.
+	Commit string
+	// GeneratedAt is when the filters were generated, in RFC 3339 format.
+	GeneratedAt string
+}
+
+// Recorded returns true if the provenance was recorded when the filters were
+// generated. Filters generated before provenance was recorded only have a
+// Provider and Source.
+func (p Provenance) Recorded() bool {
+	return p.GeneratedAt != ""
+}
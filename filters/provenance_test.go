@@ -0,0 +1,50 @@
+package filters
+
+import "testing"
+
+func TestNewProvenance(t *testing.T) {
+	providers := make(map[string]bool)
+
+	for _, p := range NewProvenance() {
+		if p.Provider == "" || p.Source == "" {
+			t.Errorf("NewProvenance(): want provider and source of every provider, got %+v", p)
+		}
+
+		if providers[p.Provider] {
+			t.Errorf("NewProvenance(): want one provenance per provider, got %q twice", p.Provider)
+		}
+
+		providers[p.Provider] = true
+	}
+
+	if len(providers) == 0 {
+		t.Error("NewProvenance(): want the provenance of the generated providers, got none")
+	}
+}
+
+func TestProvenanceRecorded(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		p      Provenance
+		want   bool
+	}{
+		"Recorded": {
+			reason: "Filters generated with provenance record when they were generated",
+			p:      Provenance{Provider: "provider-upjet-aws", Ref: "v2.7.0", Version: "v2.7.0", Commit: "0123abc", GeneratedAt: "2026-01-02T03:04:05Z"},
+			want:   true,
+		},
+		"NotRecorded": {
+			reason: "Filters generated before provenance was recorded only have a provider and source",
+			p:      Provenance{Provider: "provider-upjet-aws", Source: "https://github.com/crossplane-contrib/provider-upjet-aws.git"},
+			want:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.p.Recorded(); got != tc.want {
+				t.Errorf("%s\nRecorded(): want %t, got %t", tc.reason, tc.want, got)
+			}
+		})
+	}
+}
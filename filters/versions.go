@@ -0,0 +1,97 @@
+package filters
+
+import (
+	"k8s.io/apimachinery/pkg/util/version"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+)
+
+// VersionRange is the range of provider versions in which a kind supports
+// tags.
+type VersionRange struct {
+	// Provider is the name of the provider, like provider-upjet-aws.
+	Provider string
+	// MinVersion is the first version that supports tags. If empty, all
+	// versions before MaxVersion support tags.
+	MinVersion string
+	// MaxVersion is the first version that no longer supports tags. If
+	// empty, all versions from MinVersion support tags.
+	MaxVersion string
+}
+
+// Contains returns true if a version of the provider is in the range.
+func (r VersionRange) Contains(v string) (bool, error) {
+	installed, err := version.ParseGeneric(v)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot parse version %q of provider %q", v, r.Provider)
+	}
+
+	if r.MinVersion != "" {
+		minVersion, err := version.ParseGeneric(r.MinVersion)
+		if err != nil {
+			return false, errors.Wrapf(err, "cannot parse minimum version %q of provider %q", r.MinVersion, r.Provider)
+		}
+
+		if installed.LessThan(minVersion) {
+			return false, nil
+		}
+	}
+
+	if r.MaxVersion != "" {
+		maxVersion, err := version.ParseGeneric(r.MaxVersion)
+		if err != nil {
+			return false, errors.Wrapf(err, "cannot parse maximum version %q of provider %q", r.MaxVersion, r.Provider)
+		}
+
+		if !installed.LessThan(maxVersion) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// TagSupportVersions maps a group/Kind to the provider versions in which it
+// supports tags. Only kinds whose tag support changed between the scanned
+// provider versions have an entry.
+type TagSupportVersions map[string]VersionRange
+
+// Apply sets whether the kinds of a filter support tags in the installed
+// versions of their providers. installed maps a provider name to its
+// version. Kinds of providers without an installed version keep their entry
+// in the filter, which is their support in the latest scanned version. The
+// group/version/Kind entries of a kind get the same support as its
+// group/Kind entry.
+func (v TagSupportVersions) Apply(filter ResourceFilter, installed map[string]string) error {
+	if len(installed) == 0 {
+		return nil
+	}
+
+	versionKeys := make(map[string][]string)
+
+	for key := range filter {
+		if gk := keyGroupKind(key); gk != key && !IsPattern(key) {
+			versionKeys[gk] = append(versionKeys[gk], key)
+		}
+	}
+
+	for groupKind, r := range v {
+		iv, ok := installed[r.Provider]
+		if !ok {
+			continue
+		}
+
+		supported, err := r.Contains(iv)
+		if err != nil {
+			return errors.Wrapf(err, "cannot determine tag support of %q", groupKind)
+		}
+
+		filter[groupKind] = supported
+
+		for _, key := range versionKeys[groupKind] {
+			filter[key] = supported
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTagSupportVersionsApply(t *testing.T) {
+	versions := TagSupportVersions{
+		"cloudfront.aws.upbound.io/Function": {Provider: "provider-upjet-aws", MinVersion: "v2.7.0"},
+		"ec2.aws.upbound.io/Legacy":          {Provider: "provider-upjet-aws", MinVersion: "v1.2.0", MaxVersion: "v2.0.0"},
+		"storage.azure.upbound.io/Account":   {Provider: "provider-upjet-azure", MinVersion: "v2.7.0"},
+	}
+
+	type want struct {
+		filter ResourceFilter
+		err    bool
+	}
+
+	cases := map[string]struct {
+		reason    string
+		installed map[string]string
+		want      want
+	}{
+		"NoInstalledVersions": {
+			reason: "Without installed versions the filter is not changed",
+			want: want{filter: ResourceFilter{
+				"cloudfront.aws.upbound.io/Function":         true,
+				"cloudfront.aws.upbound.io/v1beta1/Function": true,
+				"ec2.aws.upbound.io/Legacy":                  false,
+				"ec2.aws.upbound.io/v1beta1/Legacy":          false,
+				"storage.azure.upbound.io/Account":           true,
+			}},
+		},
+		"OlderVersion": {
+			reason:    "Kinds and their API versions are not tagged in versions before their minimum version",
+			installed: map[string]string{"provider-upjet-aws": "v2.6.1"},
+			want: want{filter: ResourceFilter{
+				"cloudfront.aws.upbound.io/Function":         false,
+				"cloudfront.aws.upbound.io/v1beta1/Function": false,
+				"ec2.aws.upbound.io/Legacy":                  false,
+				"ec2.aws.upbound.io/v1beta1/Legacy":          false,
+				"storage.azure.upbound.io/Account":           true,
+			}},
+		},
+		"InRange": {
+			reason:    "Kinds and their API versions are tagged in versions in their range, and not from their maximum version",
+			installed: map[string]string{"provider-upjet-aws": "1.5.0", "provider-upjet-azure": "v2.7.0"},
+			want: want{filter: ResourceFilter{
+				"cloudfront.aws.upbound.io/Function":         false,
+				"cloudfront.aws.upbound.io/v1beta1/Function": false,
+				"ec2.aws.upbound.io/Legacy":                  true,
+				"ec2.aws.upbound.io/v1beta1/Legacy":          true,
+				"storage.azure.upbound.io/Account":           true,
+			}},
+		},
+		"MalformedVersion": {
+			reason:    "A malformed installed version is an error",
+			installed: map[string]string{"provider-upjet-aws": "latest"},
+			want:      want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			filter := ResourceFilter{
+				"cloudfront.aws.upbound.io/Function":         true,
+				"cloudfront.aws.upbound.io/v1beta1/Function": true,
+				"ec2.aws.upbound.io/Legacy":                  false,
+				"ec2.aws.upbound.io/v1beta1/Legacy":          false,
+				"storage.azure.upbound.io/Account":           true,
+			}
+
+			err := versions.Apply(filter, tc.installed)
+			if (err != nil) != tc.want.err {
+				t.Fatalf("%s\nApply(...): want error %t, got %v", tc.reason, tc.want.err, err)
+			}
+
+			if tc.want.err {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want.filter, filter); diff != "" {
+				t.Errorf("%s\nApply(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
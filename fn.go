@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/crossplane-contrib/function-tag-manager/filters"
@@ -18,11 +21,27 @@ import (
 	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
 )
 
+// Condition types and reasons that summarize tag processing.
+const (
+	// ConditionTypeTagsProcessed indicates whether tags were processed without errors.
+	ConditionTypeTagsProcessed = "TagsProcessed"
+
+	// ReasonTagsProcessed means tags were processed without warnings.
+	ReasonTagsProcessed = "Processed"
+	// ReasonTagsProcessedWithWarnings means tags were processed, but warnings were returned.
+	ReasonTagsProcessedWithWarnings = "ProcessedWithWarnings"
+	// ReasonTagsFailed means tags could not be processed.
+	ReasonTagsFailed = "Failed"
+)
+
 // Function manages tags on composed resources based on configuration rules.
 type Function struct {
 	fnv1.FunctionRunnerServiceServer
 
 	log logging.Logger
+
+	// verdicts caches whether kinds support tags, read from their CRDs.
+	verdicts VerdictCache
 }
 
 // RunFunction runs the Function.
@@ -62,10 +81,6 @@ func (f *Function) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest)
 		"xr-name", oxr.Resource.GetName(),
 	)
 
-	// Process all the AddTags into 2 groups based on Policy: Replace or Retain
-	// we also need to resolve any tags coming from a Composite fieldpath
-	additionalTags := f.ResolveAddTags(in.AddTags, oxr, env)
-
 	// The composed resources that actually exist.
 	observedComposed, err := request.GetObservedComposedResources(req)
 	if err != nil {
@@ -82,8 +97,77 @@ func (f *Function) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest)
 
 	resourceFilter := filters.NewResourceFilter()
 
-	// Resolve tags to remove once for all resources (doesn't depend on individual resources)
-	removeTags := f.ResolveRemoveTags(in.RemoveTags, oxr, env)
+	err = resourceFilter.Validate()
+	if err != nil {
+		response.Fatal(rsp, err)
+		return rsp, nil
+	}
+
+	// Only tag kinds that support tags in the installed provider versions
+	err = filters.NewTagSupportVersions().Apply(resourceFilter, in.ProviderVersions)
+	if err != nil {
+		response.Fatal(rsp, errors.Wrap(err, "cannot apply provider versions"))
+		return rsp, nil
+	}
+
+	err = ValidateResourceFilter(in.ResourceFilter)
+	if err != nil {
+		response.Fatal(rsp, err)
+		return rsp, nil
+	}
+
+	// Compile tag rules once, a rule that cannot be applied would silently
+	// drop or replace every value
+	tagRules, err := CompileTagRules(in.TagRules)
+	if err != nil {
+		response.Fatal(rsp, errors.Wrap(err, "invalid tagRules"))
+		return rsp, nil
+	}
+
+	// Required tag keys that are missing from each resource
+	missingTags := make(map[resource.Name][]string)
+
+	// Changes made to sanitize the tags of each resource
+	sanitized := make(map[resource.Name][]string)
+
+	// Errors reading sources and managing tags, and the resources they apply to
+	warnings := make(Warnings)
+
+	// Tag changes of each resource in DryRun mode
+	dryRun := in.GetMode() == v1beta1.ModeDryRun
+	diffs := make(map[resource.Name]string)
+
+	// Field paths of resources that keep tags in more than one place or shape
+	tagPaths := filters.NewTagPaths()
+
+	// Read whether kinds support tags from their CRDs, falling back to the
+	// generated filters for kinds whose CRD Crossplane has not sent yet
+	if in.GetDiscovery() == v1beta1.DiscoveryCustomResourceDefinitions {
+		RequireCRDs(rsp, desiredComposed)
+
+		crds, err := RequiredCRDs(req)
+		if err != nil {
+			response.Fatal(rsp, errors.Wrapf(err, "cannot get CustomResourceDefinitions from %T", req))
+			return rsp, nil
+		}
+
+		for name, err := range DiscoverTagSupport(desiredComposed, crds, &f.verdicts, resourceFilter, tagPaths) {
+			f.log.Debug("error discovering tag support", "resource", string(name), "error", err.Error())
+			warnings.Add(name, err)
+		}
+	}
+
+	tagListDefaults, err := TagListDefaults(in)
+	if err != nil {
+		response.Fatal(rsp, errors.Wrap(err, "cannot get tag list defaults"))
+		return rsp, nil
+	}
+
+	// Resources of kinds missing from the filters that were detected to support tags
+	detectedKinds := make(map[string][]string)
+
+	// Tags derived from the Composite are the same for every resource
+	autoTags := ResolveAutoTags(in.AutoTags, oxr)
 
 	for name, desired := range desiredComposed {
 		if IgnoreResource(desired) {
@@ -91,34 +175,181 @@ func (f *Function) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest)
 			continue
 		}
 
-		if !SupportedManagedResource(desired, resourceFilter) {
-			f.log.Debug("skipping resource that doesn't support tags", "resource", string(name), "gvk", desired.Resource.GroupVersionKind().String())
+		gvk := desired.Resource.GroupVersionKind()
+		groupKind := gvk.Group + "/" + gvk.Kind
+
+		supported, matched := MatchResource(desired, resourceFilter, in.ResourceFilter)
+		if !matched {
+			var observed *resource.ObservedComposed
+			if o, ok := observedComposed[name]; ok {
+				observed = &o
+			}
+
+			var detected bool
+
+			supported, detected = UnknownKindSupported(in.GetUnknownKinds(), desired, observed, tagPaths.GetGVK(gvk)[0])
+			if detected {
+				detectedKinds[groupKind] = append(detectedKinds[groupKind], string(name))
+			}
+		}
+
+		if !supported {
+			f.log.Debug("skipping resource that doesn't support tags", "resource", string(name), "gvk", gvk.String())
 			continue
 		}
 
-		err := MergeTags(desired, additionalTags)
+		// In DryRun mode tags are managed on a copy of the desired resource
+		original := desired
+		if dryRun {
+			desired = &resource.DesiredComposed{Resource: desired.Resource.DeepCopy(), Ready: desired.Ready}
+		}
+
+		// The field paths this resource keeps tags in, like spec.forProvider.tags
+		paths := WithTagListDefaults(ManagedTagPaths(desired, tagPaths), tagListDefaults)
+
+		// Process all the AddTags selected for this resource into 2 groups based on
+		// Policy: Replace or Retain. We also need to resolve any tags coming from a
+		// Composite fieldpath
+		additionalTags, serrs, ruleChanges, err := f.ResolveAddTags(SelectAddTags(in.AddTags, name, desired), tagRules, oxr, env, name, desired)
 		if err != nil {
-			f.log.Debug("error adding tags", "resource", string(name), "error", err.Error())
+			tagsFailed(rsp, errors.Wrapf(err, "cannot resolve tags for resource %q", name))
+			return rsp, nil
+		}
+
+		for _, c := range ruleChanges {
+			warnings.Add(name, errors.New(c))
+		}
+
+		if err := warnings.AddSourceErrors(name, serrs); err != nil {
+			tagsFailed(rsp, errors.Wrapf(err, "cannot resolve tags for resource %q", name))
+			return rsp, nil
 		}
 
-		// Ignore tags only if there is an existing Composed resource with tags in the status
-		if observed, ok := observedComposed[name]; ok {
-			ignoreTags := f.ResolveIgnoreTags(in.IgnoreTags, oxr, &observed, env)
-			if ignoreTags != nil {
-				err := MergeTags(desired, *ignoreTags)
+		removeTags, serrs := f.ResolveRemoveTags(SelectRemoveTags(in.RemoveTags, name, desired), oxr, env)
+		if err := warnings.AddSourceErrors(name, serrs); err != nil {
+			tagsFailed(rsp, errors.Wrapf(err, "cannot resolve tags to remove for resource %q", name))
+			return rsp, nil
+		}
+
+		for i, p := range paths {
+			// Add tags derived from the Composite before AddTags, so AddTags can override them
+			if in.AutoTags != nil {
+				err := MergeTags(desired, autoTags, p)
 				if err != nil {
-					f.log.Debug("error adding tags to ignore", "resource", string(name), "error", err.Error())
+					f.log.Debug("error adding automatic tags", "resource", string(name), "path", p.Desired, "error", err.Error())
+					warnings.Add(name, errors.Wrapf(err, "cannot add automatic tags to %s", p.Desired))
+				}
+			}
+
+			err = MergeTags(desired, additionalTags, p)
+			if err != nil {
+				f.log.Debug("error adding tags", "resource", string(name), "path", p.Desired, "error", err.Error())
+				warnings.Add(name, errors.Wrapf(err, "cannot add tags to %s", p.Desired))
+			}
+
+			// Ignore tags only if there is an existing Composed resource with tags in the status
+			if observed, ok := observedComposed[name]; ok {
+				ignoreTags, serrs := f.ResolveIgnoreTags(SelectIgnoreTags(in.IgnoreTags, name, desired), oxr, &observed, env, p)
+				if err := warnings.AddSourceErrors(name, serrs); err != nil {
+					tagsFailed(rsp, errors.Wrapf(err, "cannot resolve tags to ignore for resource %q", name))
+					return rsp, nil
+				}
+
+				if ignoreTags != nil {
+					err := MergeTags(desired, *ignoreTags, p)
+					if err != nil {
+						f.log.Debug("error adding tags to ignore", "resource", string(name), "path", p.Desired, "error", err.Error())
+						warnings.Add(name, errors.Wrapf(err, "cannot add tags to ignore to %s", p.Desired))
+					}
 				}
 			}
-		}
 
-		// Remove tags
-		if len(removeTags) > 0 {
-			err := RemoveTags(desired, removeTags)
+			// Remove tags
+			if len(removeTags) > 0 {
+				err := RemoveTags(desired, removeTags, p)
+				if err != nil {
+					f.log.Debug("error removing tags", "resource", string(name), "path", p.Desired, "error", err.Error())
+					warnings.Add(name, errors.Wrapf(err, "cannot remove tags from %s", p.Desired))
+				}
+			}
+
+			// Normalize tags of providers with strict rules, like GCP labels
+			dropped, err := NormalizeResourceTags(desired, p)
 			if err != nil {
-				f.log.Debug("error removing tags", "resource", string(name), "error", err.Error())
+				f.log.Debug("error normalizing tags", "resource", string(name), "path", p.Desired, "error", err.Error())
+				warnings.Add(name, errors.Wrapf(err, "cannot normalize tags of %s", p.Desired))
+			}
+
+			for _, d := range dropped {
+				warnings.Add(name, errors.New(d))
+			}
+
+			// Fix tags that the resource's provider would reject
+			if in.Sanitize != nil {
+				changes, err := SanitizeResourceTags(desired, in.Sanitize, p)
+				if err != nil {
+					tagsFailed(rsp, errors.Wrapf(err, "cannot sanitize tags for resource %q", name))
+					return rsp, nil
+				}
+
+				for _, c := range changes {
+					if i > 0 {
+						c = p.Desired + ": " + c
+					}
+
+					sanitized[name] = append(sanitized[name], c)
+				}
+			}
+		}
+
+		// Check required tags against the final primary tags of the resource
+		if in.RequiredTags != nil {
+			if missing := MissingTags(desired, in.RequiredTags.Keys, paths[0]); len(missing) > 0 {
+				missingTags[name] = missing
 			}
 		}
+
+		if dryRun {
+			diffs[name] = FormatTagDiffs(original, desired, paths)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(diffs)) {
+		response.Normalf(rsp, "DryRun: resource %q: %s", name, diffs[name])
+	}
+
+	for _, groupKind := range slices.Sorted(maps.Keys(detectedKinds)) {
+		names := detectedKinds[groupKind]
+		slices.Sort(names)
+		response.Normalf(rsp, "tagging resources of kind %q that is missing from the resource filter because they already have tags: %s", groupKind, strings.Join(names, ", "))
+	}
+
+	numWarnings := warnings.Report(rsp)
+
+	for _, name := range slices.Sorted(maps.Keys(sanitized)) {
+		response.Warning(rsp, errors.Errorf("sanitized tags of resource %q: %s", name, strings.Join(sanitized[name], "; ")))
+		numWarnings++
+	}
+
+	if len(missingTags) > 0 {
+		if in.RequiredTags.GetEnforcement() == v1beta1.EnforcementFatal {
+			tagsFailed(rsp, errors.Errorf("resources are missing required tags: %s", FormatMissingTags(missingTags)))
+			return rsp, nil
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(missingTags)) {
+			response.Warning(rsp, errors.Errorf("resource %q is missing required tags: %s", name, strings.Join(missingTags[name], ", ")))
+			numWarnings++
+		}
+	}
+
+	if numWarnings > 0 {
+		response.ConditionFalse(rsp, ConditionTypeTagsProcessed, ReasonTagsProcessedWithWarnings).
+			WithMessage(fmt.Sprintf("Processed tags with %d warnings", numWarnings)).
+			TargetCompositeAndClaim()
+	} else {
+		response.ConditionTrue(rsp, ConditionTypeTagsProcessed, ReasonTagsProcessed).
+			TargetCompositeAndClaim()
 	}
 
 	err = response.SetDesiredComposedResources(rsp, desiredComposed)
@@ -127,11 +358,25 @@ func (f *Function) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest)
 		return rsp, nil
 	}
 
+	if dryRun {
+		response.Normalf(rsp, "Successfully Processed tags in DryRun mode, desired resources were not changed")
+		return rsp, nil
+	}
+
 	response.Normalf(rsp, "Successfully Processed tags")
 
 	return rsp, nil
 }
 
+// tagsFailed returns a fatal result and sets the tag processing condition
+// of the composite resource to false.
+func tagsFailed(rsp *fnv1.RunFunctionResponse, err error) {
+	response.Fatal(rsp, err)
+	response.ConditionFalse(rsp, ConditionTypeTagsProcessed, ReasonTagsFailed).
+		WithMessage(err.Error()).
+		TargetCompositeAndClaim()
+}
+
 // IgnoreResource whether this resource has a label or annotation set to ignore.
 // If the annotation is present, it takes precedence over the label.
 func IgnoreResource(dc *resource.DesiredComposed) bool {
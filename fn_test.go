@@ -130,6 +130,14 @@ func TestRunFunction(t *testing.T) {
 			},
 			want: want{
 				rsp: &fnv1.RunFunctionResponse{
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   ConditionTypeTagsProcessed,
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: ReasonTagsProcessed,
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
 					Desired: &fnv1.State{},
 					Meta:    &fnv1.ResponseMeta{Tag: "tag-manager", Ttl: durationpb.New(response.DefaultTTL)},
 					Results: []*fnv1.Result{
@@ -142,6 +150,529 @@ func TestRunFunction(t *testing.T) {
 				},
 			},
 		},
+		"RequiredTagsWarning": {
+			reason: "The Function should return a warning for each resource missing required tags",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "tag-manager"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "tag-manger.fn.crossplane.io/v1beta1",
+						"kind": "ManagedTags",
+						"addTags": [
+						  {
+							"type": "FromValue",
+							"tags": {
+							  "owner": "platform"
+							}
+						  }
+						],
+						"requiredTags": {
+						  "keys": ["owner", "cost-center"]
+						}
+					  }`),
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"vpc": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "ec2.aws.upbound.io/v1beta1",
+								"kind": "VPC",
+								"spec": {"forProvider": {"region": "us-west-2"}}
+							}`)},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   ConditionTypeTagsProcessed,
+							Status: fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason: ReasonTagsProcessedWithWarnings,
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"vpc": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "ec2.aws.upbound.io/v1beta1",
+								"kind": "VPC",
+								"spec": {"forProvider": {"region": "us-west-2", "tags": {"owner": "platform"}}}
+							}`)},
+						},
+					},
+					Meta: &fnv1.ResponseMeta{Tag: "tag-manager", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  `resource "vpc" is missing required tags: cost-center`,
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully Processed tags",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"RequiredTagsFatal": {
+			reason: "The Function should return a fatal result when a resource is missing required tags and enforcement is Fatal",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "tag-manager"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "tag-manger.fn.crossplane.io/v1beta1",
+						"kind": "ManagedTags",
+						"requiredTags": {
+						  "keys": ["owner"],
+						  "enforcement": "Fatal"
+						}
+					  }`),
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"vpc": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "ec2.aws.upbound.io/v1beta1",
+								"kind": "VPC",
+								"spec": {"forProvider": {"region": "us-west-2"}}
+							}`)},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   ConditionTypeTagsProcessed,
+							Status: fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason: ReasonTagsFailed,
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"vpc": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "ec2.aws.upbound.io/v1beta1",
+								"kind": "VPC",
+								"spec": {"forProvider": {"region": "us-west-2"}}
+							}`)},
+						},
+					},
+					Meta: &fnv1.ResponseMeta{Tag: "tag-manager", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_FATAL,
+							Message:  "resources are missing required tags: vpc (owner)",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"InvalidTagRulePattern": {
+			reason: "The Function should return a fatal result when a tag rule pattern cannot be compiled, instead of dropping every value",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "tag-manager"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "tag-manger.fn.crossplane.io/v1beta1",
+						"kind": "ManagedTags",
+						"tagRules": [
+						  {
+							"key": "cost-center",
+							"pattern": "^CC-[0-9]{4$",
+							"action": "Drop"
+						  }
+						]
+					  }`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "tag-manager", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_FATAL,
+							Message:  `invalid tagRules: cannot compile pattern "^CC-[0-9]{4$" of tag rule for "cost-center": incomplete repetition at offset 9, escape literal braces with \{`,
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"TagRuleDropWarning": {
+			reason: "The Function should return a warning for values dropped by a tag rule",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "tag-manager"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "tag-manger.fn.crossplane.io/v1beta1",
+						"kind": "ManagedTags",
+						"addTags": [
+						  {
+							"type": "FromCompositeFieldPath",
+							"fromFieldPath": "spec.additionalTags"
+						  }
+						],
+						"tagRules": [
+						  {
+							"key": "environment",
+							"allowedValues": ["dev", "stage", "prod"]
+						  }
+						]
+					  }`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{Resource: resource.MustStructJSON(`{
+							"apiVersion": "example.crossplane.io/v1",
+							"kind": "XNetwork",
+							"spec": {"additionalTags": {"environment": "qa", "owner": "platform"}}
+						}`)},
+					},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"vpc": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "ec2.aws.upbound.io/v1beta1",
+								"kind": "VPC",
+								"spec": {"forProvider": {"region": "us-west-2"}}
+							}`)},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   ConditionTypeTagsProcessed,
+							Status: fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason: ReasonTagsProcessedWithWarnings,
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"vpc": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "ec2.aws.upbound.io/v1beta1",
+								"kind": "VPC",
+								"spec": {"forProvider": {"region": "us-west-2", "tags": {"owner": "platform"}}}
+							}`)},
+						},
+					},
+					Meta: &fnv1.ResponseMeta{Tag: "tag-manager", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  `FromCompositeFieldPath field "spec.additionalTags": dropped invalid value "qa" of tag "environment": value must be one of [dev stage prod] (resources: vpc)`,
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully Processed tags",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"DryRun": {
+			reason: "The Function should report tag changes without changing desired resources in DryRun mode",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "tag-manager"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "tag-manger.fn.crossplane.io/v1beta1",
+						"kind": "ManagedTags",
+						"mode": "DryRun",
+						"addTags": [
+						  {
+							"type": "FromValue",
+							"tags": {
+							  "owner": "platform",
+							  "environment": "prod"
+							}
+						  }
+						],
+						"removeTags": [
+						  {
+							"type": "FromValue",
+							"keys": ["old"]
+						  }
+						]
+					  }`),
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"vpc": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "ec2.aws.upbound.io/v1beta1",
+								"kind": "VPC",
+								"spec": {"forProvider": {"region": "us-west-2", "tags": {"environment": "dev", "old": "value"}}}
+							}`)},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   ConditionTypeTagsProcessed,
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: ReasonTagsProcessed,
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"vpc": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "ec2.aws.upbound.io/v1beta1",
+								"kind": "VPC",
+								"spec": {"forProvider": {"region": "us-west-2", "tags": {"environment": "dev", "old": "value"}}}
+							}`)},
+						},
+					},
+					Meta: &fnv1.ResponseMeta{Tag: "tag-manager", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  `DryRun: resource "vpc": add owner; change environment; remove old`,
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully Processed tags in DryRun mode, desired resources were not changed",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"KeyValueListTags": {
+			reason: "The Function should manage tags that are a list of key/value objects, keeping extra fields and setting defaults on new entries",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "tag-manager"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "tag-manger.fn.crossplane.io/v1beta1",
+						"kind": "ManagedTags",
+						"tagListDefaults": {"propagateAtLaunch": false},
+						"addTags": [
+						  {
+							"type": "FromValue",
+							"tags": {
+							  "owner": "platform",
+							  "environment": "prod"
+							}
+						  }
+						]
+					  }`),
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"asg": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "autoscaling.aws.upbound.io/v1beta1",
+								"kind": "AutoscalingGroup",
+								"spec": {"forProvider": {"region": "us-west-2", "tag": [
+									{"key": "environment", "value": "dev", "propagateAtLaunch": true}
+								]}}
+							}`)},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   ConditionTypeTagsProcessed,
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: ReasonTagsProcessed,
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"asg": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "autoscaling.aws.upbound.io/v1beta1",
+								"kind": "AutoscalingGroup",
+								"spec": {"forProvider": {"region": "us-west-2", "tag": [
+									{"key": "environment", "value": "prod", "propagateAtLaunch": true},
+									{"key": "owner", "value": "platform", "propagateAtLaunch": false}
+								]}}
+							}`)},
+						},
+					},
+					Meta: &fnv1.ResponseMeta{Tag: "tag-manager", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully Processed tags",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"UnknownKindDetect": {
+			reason: "The Function should tag resources of unknown kinds that already have tags and report their kind",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "tag-manager"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "tag-manger.fn.crossplane.io/v1beta1",
+						"kind": "ManagedTags",
+						"unknownKinds": "Detect",
+						"addTags": [
+						  {
+							"type": "FromValue",
+							"tags": {"owner": "platform"}
+						  }
+						]
+					  }`),
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"database": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "db.platform.example.com/v1",
+								"kind": "Database",
+								"spec": {"forProvider": {"tags": {"team": "data"}}}
+							}`)},
+							"queue": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "mq.platform.example.com/v1",
+								"kind": "Queue",
+								"spec": {"forProvider": {}}
+							}`)},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   ConditionTypeTagsProcessed,
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: ReasonTagsProcessed,
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"database": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "db.platform.example.com/v1",
+								"kind": "Database",
+								"spec": {"forProvider": {"tags": {"team": "data", "owner": "platform"}}}
+							}`)},
+							"queue": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "mq.platform.example.com/v1",
+								"kind": "Queue",
+								"spec": {"forProvider": {}}
+							}`)},
+						},
+					},
+					Meta: &fnv1.ResponseMeta{Tag: "tag-manager", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  `tagging resources of kind "db.platform.example.com/Database" that is missing from the resource filter because they already have tags: database`,
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully Processed tags",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"DiscoverCustomResourceDefinitions": {
+			reason: "The Function should require the CRDs of composed resources and tag kinds whose schema has tags",
+			args: args{
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "tag-manager"},
+					Input: resource.MustStructJSON(`{
+						"apiVersion": "tag-manger.fn.crossplane.io/v1beta1",
+						"kind": "ManagedTags",
+						"discovery": "CustomResourceDefinitions",
+						"addTags": [
+						  {
+							"type": "FromValue",
+							"tags": {"owner": "platform"}
+						  }
+						]
+					  }`),
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"widget": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "example.aws.upbound.io/v1beta1",
+								"kind": "Widget",
+								"spec": {"forProvider": {"region": "us-west-2"}}
+							}`)},
+						},
+					},
+					RequiredResources: map[string]*fnv1.Resources{
+						"widgets.example.aws.upbound.io": {Items: []*fnv1.Resource{{Resource: resource.MustStructJSON(`{
+							"apiVersion": "apiextensions.k8s.io/v1",
+							"kind": "CustomResourceDefinition",
+							"metadata": {"name": "widgets.example.aws.upbound.io"},
+							"spec": {
+								"group": "example.aws.upbound.io",
+								"names": {"kind": "Widget", "plural": "widgets"},
+								"scope": "Cluster",
+								"versions": [{
+									"name": "v1beta1",
+									"served": true,
+									"storage": true,
+									"schema": {"openAPIV3Schema": {"type": "object", "properties": {
+										"spec": {"type": "object", "properties": {
+											"forProvider": {"type": "object", "properties": {
+												"tags": {"type": "object"}
+											}}
+										}}
+									}}}
+								}]
+							}
+						}`)}}},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   ConditionTypeTagsProcessed,
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: ReasonTagsProcessed,
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"widget": {Resource: resource.MustStructJSON(`{
+								"apiVersion": "example.aws.upbound.io/v1beta1",
+								"kind": "Widget",
+								"spec": {"forProvider": {"region": "us-west-2", "tags": {"owner": "platform"}}}
+							}`)},
+						},
+					},
+					Meta: &fnv1.ResponseMeta{Tag: "tag-manager", Ttl: durationpb.New(response.DefaultTTL)},
+					Requirements: &fnv1.Requirements{
+						Resources: map[string]*fnv1.ResourceSelector{
+							"widgets.example.aws.upbound.io": {
+								ApiVersion: "apiextensions.k8s.io/v1",
+								Kind:       "CustomResourceDefinition",
+								Match:      &fnv1.ResourceSelector_MatchName{MatchName: "widgets.example.aws.upbound.io"},
+							},
+						},
+					},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  "Successfully Processed tags",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range cases {
@@ -151,6 +682,7 @@ func TestRunFunction(t *testing.T) {
 
 			if diff := cmp.Diff(tc.want.rsp, rsp,
 				protocmp.IgnoreFields(&fnv1.Result{}, "message"), // ignore error messages on parsing input
+				protocmp.IgnoreFields(&fnv1.Condition{}, "message"),
 				protocmp.Transform()); diff != "" {
 				t.Errorf("%s\nf.RunFunction(...): -want rsp, +got rsp:\n%s", tc.reason, diff)
 			}
@@ -5,6 +5,7 @@
 package v1beta1
 
 import (
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -19,6 +20,50 @@ type ManagedTags struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 
+	// Mode determines whether the function changes desired resources. Apply
+	// (default) manages tags, while DryRun reports the tags that would be
+	// added, changed or removed without changing desired resources.
+	// +kubebuilder:validation:Enum=Apply;DryRun
+	// +optional
+	Mode Mode `json:"mode,omitempty"`
+
+	// ResourceFilter overrides whether kinds support tags, for example when
+	// a provider release adds tag support to a kind before this function's
+	// generated filters do.
+	// +optional
+	ResourceFilter *ResourceFilter `json:"resourceFilter,omitempty"`
+
+	// ProviderVersions are the installed versions of providers, keyed by
+	// provider name like provider-upjet-aws. Kinds that only support tags
+	// in some versions of a provider are only tagged when the installed
+	// version supports them. Without a version, kinds are tagged if the
+	// latest version the filters were generated from supports them.
+	// +optional
+	ProviderVersions map[string]string `json:"providerVersions,omitempty"`
+
+	// Discovery determines how the function learns whether a kind supports
+	// tags. Generated (default) uses the filters generated from provider
+	// CRDs when the function was built. CustomResourceDefinitions requests
+	// the CRDs of composed resources from Crossplane and reads their
+	// schemas, falling back to the generated filters when a CRD is not
+	// available.
+	// +kubebuilder:validation:Enum=Generated;CustomResourceDefinitions
+	// +optional
+	Discovery Discovery `json:"discovery,omitempty"`
+
+	// UnknownKinds determines whether composed resources of kinds that are
+	// missing from the filters are tagged. Skip (default) does not tag them.
+	// Detect tags them when their desired or observed state already has
+	// tags, and reports their kinds. Tag tags all of them.
+	// +kubebuilder:validation:Enum=Skip;Detect;Tag
+	// +optional
+	UnknownKinds UnknownKindPolicy `json:"unknownKinds,omitempty"`
+
+	// AutoTags are tags derived from the observed composite resource, like its
+	// name, kind and claim, that are added to every composed resource.
+	// +optional
+	AutoTags *AutoTags `json:"autoTags,omitempty"`
+
 	// AddTags are fields that will be added to every composed resource.
 	// +optional
 	AddTags []AddTag `json:"addTags,omitempty"`
@@ -31,8 +76,63 @@ type ManagedTags struct {
 	// IgnoreTags is a list of tag keys to remove from the resource.
 	// +optional
 	RemoveTags RemoveTags `json:"removeTags,omitempty"`
+
+	// RequiredTags are tag keys every resource that supports tags must have
+	// after tags have been added, ignored and removed.
+	// +optional
+	RequiredTags *RequiredTags `json:"requiredTags,omitempty"`
+
+	// TagRules validate the values of tags read from the Composite or the
+	// Environment before they are added to resources.
+	// +optional
+	TagRules []TagRule `json:"tagRules,omitempty"`
+
+	// TagListDefaults are the values of extra fields set on new entries of
+	// tags that are a list of key/value objects, like propagateAtLaunch on
+	// AWS AutoscalingGroups. They override the function's defaults.
+	// +optional
+	TagListDefaults map[string]extv1.JSON `json:"tagListDefaults,omitempty"`
+
+	// Sanitize fixes tags that break the limits of the resource's cloud
+	// provider before they are sent to the provider. If unset, tags are
+	// not sanitized.
+	// +optional
+	Sanitize *Sanitize `json:"sanitize,omitempty"`
 }
 
+// Mode sets whether the function changes desired resources.
+type Mode string
+
+const (
+	// ModeApply manages tags on desired resources.
+	ModeApply Mode = "Apply"
+	// ModeDryRun reports tag changes without changing desired resources.
+	ModeDryRun Mode = "DryRun"
+)
+
+// Discovery sets how the function learns whether a kind supports tags.
+type Discovery string
+
+const (
+	// DiscoveryGenerated uses the filters generated at build time.
+	DiscoveryGenerated Discovery = "Generated"
+	// DiscoveryCustomResourceDefinitions reads the CRDs of composed
+	// resources at runtime.
+	DiscoveryCustomResourceDefinitions Discovery = "CustomResourceDefinitions"
+)
+
+// UnknownKindPolicy sets whether kinds missing from the filters are tagged.
+type UnknownKindPolicy string
+
+const (
+	// UnknownKindsSkip does not tag unknown kinds.
+	UnknownKindsSkip UnknownKindPolicy = "Skip"
+	// UnknownKindsDetect tags unknown kinds that already have tags.
+	UnknownKindsDetect UnknownKindPolicy = "Detect"
+	// UnknownKindsTag tags all unknown kinds.
+	UnknownKindsTag UnknownKindPolicy = "Tag"
+)
+
 // Tags contains a map tags.
 type Tags map[string]string
 
@@ -46,6 +146,8 @@ const (
 	FromValue TagManagerType = "FromValue"
 	// FromEnvironmentFieldPath instructs the function to get tag settings from the Environment fieldpath.
 	FromEnvironmentFieldPath TagManagerType = "FromEnvironmentFieldPath"
+	// FromTemplate instructs the function to render tag keys and values as Go templates.
+	FromTemplate TagManagerType = "FromTemplate"
 )
 
 // TagManagerPolicy sets what happens when the tag exists in the resource.
@@ -62,8 +164,9 @@ const (
 type AddTag struct {
 	// Type determines where tags are sourced from. FromValue are inline
 	// to the composition. FromCompositeFieldPath fetches tags from a field in
-	// the composite resource
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;FromValue
+	// the composite resource. FromTemplate renders the keys and values of tags
+	// as Go templates.
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;FromValue;FromEnvironmentFieldPath;FromTemplate
 	// +optional
 	Type TagManagerType `json:"type,omitempty"`
 
@@ -72,7 +175,10 @@ type AddTag struct {
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
-	// Tags are tags to add to the resource in the form of a map
+	// Tags are tags to add to the resource in the form of a map. If type is
+	// FromTemplate, keys and values are Go templates evaluated against the
+	// observed composite (.xr), the environment (.env), the desired composed
+	// resource (.resource) and its composition resource name (.name).
 	// + optional
 	Tags Tags `json:"tags,omitempty"`
 
@@ -82,6 +188,17 @@ type AddTag struct {
 	// +kubebuilder:validation:Enum=Replace;Retain
 	// +optional
 	Policy TagManagerPolicy `json:"policy,omitempty"`
+
+	// Required fails the function if tags cannot be read from FromFieldPath
+	// or the template cannot be rendered. Otherwise a warning is returned,
+	// and a field that does not exist is skipped.
+	// +optional
+	Required bool `json:"required,omitempty"`
+
+	// ResourceSelector limits these tags to matching composed resources.
+	// If unset, the tags are added to every resource.
+	// +optional
+	ResourceSelector *ResourceSelector `json:"resourceSelector,omitempty"`
 }
 
 // IgnoreTag is a tag that is "ignored" by setting the desired value to the observed value.
@@ -89,7 +206,7 @@ type IgnoreTag struct {
 	// Type determines where tag keys are sourced from. FromValue are inline
 	// to the composition. FromCompositeFieldPath fetches keys from a field in
 	// the composite resource
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;FromValue
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;FromValue;FromEnvironmentFieldPath
 	Type TagManagerType `json:"type"`
 
 	// FromFieldPath if type is FromCompositeFieldPath, get keys to ignore
@@ -104,6 +221,17 @@ type IgnoreTag struct {
 	// +kubebuilder:validation:Enum=Replace;Retain
 	// +optional
 	Policy TagManagerPolicy `json:"policy,omitempty"`
+
+	// Required fails the function if keys cannot be read from FromFieldPath.
+	// Otherwise a warning is returned, and a field that does not exist is
+	// skipped.
+	// +optional
+	Required bool `json:"required,omitempty"`
+
+	// ResourceSelector limits these keys to matching composed resources.
+	// If unset, the keys are ignored on every resource.
+	// +optional
+	ResourceSelector *ResourceSelector `json:"resourceSelector,omitempty"`
 }
 
 // IgnoreTags is a list of IgnoreTag settings.
@@ -114,7 +242,7 @@ type RemoveTag struct {
 	// Type determines where tag keys are sourced from. FromValue are inline
 	// to the composition. FromCompositeFieldPath fetches keys from a field in
 	// the composite resource
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;FromValue
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;FromValue;FromEnvironmentFieldPath
 	Type TagManagerType `json:"type"`
 
 	// FromFieldPath if type is FromCompositeFieldPath, get keys to remove
@@ -125,11 +253,221 @@ type RemoveTag struct {
 	// Keys are tag keys to ignore for the FromValue type
 	// +optional
 	Keys []string `json:"keys,omitempty"`
+
+	// Required fails the function if keys cannot be read from FromFieldPath.
+	// Otherwise a warning is returned, and a field that does not exist is
+	// skipped.
+	// +optional
+	Required bool `json:"required,omitempty"`
+
+	// ResourceSelector limits these keys to matching composed resources.
+	// If unset, the keys are removed from every resource.
+	// +optional
+	ResourceSelector *ResourceSelector `json:"resourceSelector,omitempty"`
 }
 
 // RemoveTags is an array of RemoveTag settings.
 type RemoveTags []RemoveTag
 
+// ResourceFilter overrides the generated filters of kinds that support tags.
+type ResourceFilter struct {
+	// Include are group/Kind patterns of kinds that support tags, like
+	// "cloudfront.aws.upbound.io/Function" or "*.platform.example.com/*".
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude are group/Kind patterns of kinds that do not support tags.
+	// Exclude takes precedence over Include.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// ResourceSelector selects composed resources. Every field that is set must
+// match for a resource to be selected. Within a list, any entry may match.
+type ResourceSelector struct {
+	// Names are composition resource names. Glob patterns like "subnet-*"
+	// are supported.
+	// +optional
+	Names []string `json:"names,omitempty"`
+
+	// APIGroups are API groups of the composed resource, like
+	// "ec2.aws.upbound.io". Glob patterns like "*.aws.upbound.io" are supported.
+	// +optional
+	APIGroups []string `json:"apiGroups,omitempty"`
+
+	// Kinds are kinds of the composed resource, like "VPC". Glob patterns
+	// are supported.
+	// +optional
+	Kinds []string `json:"kinds,omitempty"`
+
+	// MatchLabels are labels that must all be present on the desired
+	// composed resource.
+	// +optional
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+// AutoTagField is a field of the composite resource used as an automatic tag.
+type AutoTagField string
+
+const (
+	// AutoTagName is the name of the composite resource.
+	AutoTagName AutoTagField = "Name"
+	// AutoTagNamespace is the namespace of the composite resource.
+	AutoTagNamespace AutoTagField = "Namespace"
+	// AutoTagKind is the kind of the composite resource.
+	AutoTagKind AutoTagField = "Kind"
+	// AutoTagAPIVersion is the API version of the composite resource.
+	AutoTagAPIVersion AutoTagField = "APIVersion"
+	// AutoTagUID is the UID of the composite resource.
+	AutoTagUID AutoTagField = "UID"
+	// AutoTagComposition is the name of the composite resource's Composition.
+	AutoTagComposition AutoTagField = "Composition"
+	// AutoTagCompositionRevision is the name of the composite resource's CompositionRevision.
+	AutoTagCompositionRevision AutoTagField = "CompositionRevision"
+	// AutoTagClaimName is the name of the claim of the composite resource.
+	AutoTagClaimName AutoTagField = "ClaimName"
+	// AutoTagClaimNamespace is the namespace of the claim of the composite resource.
+	AutoTagClaimNamespace AutoTagField = "ClaimNamespace"
+)
+
+// AutoTags configures tags derived from the observed composite resource.
+type AutoTags struct {
+	// Fields are the composite resource fields to add as tags. If unset,
+	// all fields are added. Fields without a value, like the namespace of a
+	// cluster scoped composite, are skipped.
+	// +kubebuilder:validation:items:Enum=Name;Namespace;Kind;APIVersion;UID;Composition;CompositionRevision;ClaimName;ClaimNamespace
+	// +optional
+	Fields []AutoTagField `json:"fields,omitempty"`
+
+	// Prefix is prepended to every tag key. Defaults to "crossplane-".
+	// +optional
+	Prefix *string `json:"prefix,omitempty"`
+
+	// Keys overrides the tag key of a field, like ClaimName: owner-claim.
+	// The prefix is prepended to overridden keys.
+	// +optional
+	Keys map[AutoTagField]string `json:"keys,omitempty"`
+
+	// Policy to use when merging automatic tags. Automatic tags are merged
+	// before addTags, so addTags with the Replace policy override them.
+	// +kubebuilder:validation:Enum=Replace;Retain
+	// +optional
+	Policy TagManagerPolicy `json:"policy,omitempty"`
+}
+
+// EnforcementPolicy sets what happens when a resource does not comply.
+type EnforcementPolicy string
+
+const (
+	// EnforcementFatal fails the function when a resource does not comply.
+	EnforcementFatal EnforcementPolicy = "Fatal"
+	// EnforcementWarning returns a warning when a resource does not comply.
+	EnforcementWarning EnforcementPolicy = "Warning"
+)
+
+// RequiredTags are tag keys that must be set on every resource.
+type RequiredTags struct {
+	// Keys are the tag keys that must be set.
+	Keys []string `json:"keys"`
+
+	// Enforcement determines what happens when a resource is missing a
+	// required tag. Fatal fails the function, while Warning reports the
+	// missing tags and continues.
+	// +kubebuilder:validation:Enum=Fatal;Warning
+	// +optional
+	Enforcement EnforcementPolicy `json:"enforcement,omitempty"`
+}
+
+// TagRuleAction sets what happens when a tag value breaks a rule.
+type TagRuleAction string
+
+const (
+	// TagRuleActionDrop drops the invalid tag.
+	TagRuleActionDrop TagRuleAction = "Drop"
+	// TagRuleActionUseDefault replaces the invalid value with the rule's default.
+	TagRuleActionUseDefault TagRuleAction = "UseDefault"
+	// TagRuleActionFail fails the function.
+	TagRuleActionFail TagRuleAction = "Fail"
+)
+
+// TagRule restricts the values of a tag key.
+type TagRule struct {
+	// Key is the tag key the rule applies to.
+	Key string `json:"key"`
+
+	// AllowedValues is a list of values the tag may have.
+	// +optional
+	AllowedValues []string `json:"allowedValues,omitempty"`
+
+	// Pattern is a regular expression the value must match.
+	// +optional
+	Pattern *string `json:"pattern,omitempty"`
+
+	// MinLength is the minimum length of the value.
+	// +optional
+	MinLength *int `json:"minLength,omitempty"`
+
+	// MaxLength is the maximum length of the value.
+	// +optional
+	MaxLength *int `json:"maxLength,omitempty"`
+
+	// Action determines what happens when a value breaks the rule. Drop
+	// removes the tag, UseDefault sets the value to Default and Fail
+	// fails the function.
+	// +kubebuilder:validation:Enum=Drop;UseDefault;Fail
+	// +optional
+	Action TagRuleAction `json:"action,omitempty"`
+
+	// Default is the value used when Action is UseDefault.
+	// +optional
+	Default *string `json:"default,omitempty"`
+}
+
+// SanitizeAction sets what happens when a tag breaks a provider limit.
+type SanitizeAction string
+
+const (
+	// SanitizeActionTruncate truncates keys and values to the maximum length.
+	SanitizeActionTruncate SanitizeAction = "Truncate"
+	// SanitizeActionReplace replaces invalid characters.
+	SanitizeActionReplace SanitizeAction = "Replace"
+	// SanitizeActionDrop drops the tag.
+	SanitizeActionDrop SanitizeAction = "Drop"
+	// SanitizeActionFail fails the function.
+	SanitizeActionFail SanitizeAction = "Fail"
+)
+
+// Sanitize configures how tags that break provider limits are fixed.
+type Sanitize struct {
+	// TooLong sets the action for keys and values over the maximum length.
+	// Defaults to Truncate.
+	// +kubebuilder:validation:Enum=Truncate;Drop;Fail
+	// +optional
+	TooLong SanitizeAction `json:"tooLong,omitempty"`
+
+	// InvalidCharacters sets the action for keys with characters the
+	// provider rejects. Defaults to Replace.
+	// +kubebuilder:validation:Enum=Replace;Drop;Fail
+	// +optional
+	InvalidCharacters SanitizeAction `json:"invalidCharacters,omitempty"`
+
+	// Replacement replaces invalid characters. Defaults to "_".
+	// +optional
+	Replacement *string `json:"replacement,omitempty"`
+
+	// ReservedPrefix sets the action for keys with a prefix reserved by
+	// the provider, like "aws:". Defaults to Drop.
+	// +kubebuilder:validation:Enum=Drop;Fail
+	// +optional
+	ReservedPrefix SanitizeAction `json:"reservedPrefix,omitempty"`
+
+	// TooMany sets the action for resources with more tags than the provider
+	// allows. Drop removes tags over the limit in key order. Defaults to Drop.
+	// +kubebuilder:validation:Enum=Drop;Fail
+	// +optional
+	TooMany SanitizeAction `json:"tooMany,omitempty"`
+}
+
 // GetType returns the type of the managed tag.
 func (a *AddTag) GetType() TagManagerType {
 	if a == nil || a.Type == "" {
@@ -174,3 +512,123 @@ func (a *RemoveTag) GetType() TagManagerType {
 
 	return a.Type
 }
+
+// GetFields returns the composite resource fields to add as tags.
+func (a *AutoTags) GetFields() []AutoTagField {
+	if a == nil || len(a.Fields) == 0 {
+		return []AutoTagField{
+			AutoTagName, AutoTagNamespace, AutoTagKind, AutoTagAPIVersion, AutoTagUID,
+			AutoTagComposition, AutoTagCompositionRevision, AutoTagClaimName, AutoTagClaimNamespace,
+		}
+	}
+
+	return a.Fields
+}
+
+// GetPrefix returns the prefix of automatic tag keys.
+func (a *AutoTags) GetPrefix() string {
+	if a == nil || a.Prefix == nil {
+		return "crossplane-"
+	}
+
+	return *a.Prefix
+}
+
+// GetPolicy returns the automatic tag policy.
+func (a *AutoTags) GetPolicy() TagManagerPolicy {
+	if a == nil || a.Policy == "" {
+		return ExistingTagPolicyReplace
+	}
+
+	return a.Policy
+}
+
+// GetEnforcement returns the required tags enforcement policy.
+func (r *RequiredTags) GetEnforcement() EnforcementPolicy {
+	if r == nil || r.Enforcement == "" {
+		return EnforcementWarning
+	}
+
+	return r.Enforcement
+}
+
+// GetAction returns the tag rule action.
+func (r *TagRule) GetAction() TagRuleAction {
+	if r == nil || r.Action == "" {
+		return TagRuleActionDrop
+	}
+
+	return r.Action
+}
+
+// GetTooLong returns the action for keys and values over the maximum length.
+func (s *Sanitize) GetTooLong() SanitizeAction {
+	if s == nil || s.TooLong == "" {
+		return SanitizeActionTruncate
+	}
+
+	return s.TooLong
+}
+
+// GetInvalidCharacters returns the action for keys with invalid characters.
+func (s *Sanitize) GetInvalidCharacters() SanitizeAction {
+	if s == nil || s.InvalidCharacters == "" {
+		return SanitizeActionReplace
+	}
+
+	return s.InvalidCharacters
+}
+
+// GetReplacement returns the replacement for invalid characters.
+func (s *Sanitize) GetReplacement() string {
+	if s == nil || s.Replacement == nil {
+		return "_"
+	}
+
+	return *s.Replacement
+}
+
+// GetReservedPrefix returns the action for keys with a reserved prefix.
+func (s *Sanitize) GetReservedPrefix() SanitizeAction {
+	if s == nil || s.ReservedPrefix == "" {
+		return SanitizeActionDrop
+	}
+
+	return s.ReservedPrefix
+}
+
+// GetTooMany returns the action for resources with too many tags.
+func (s *Sanitize) GetTooMany() SanitizeAction {
+	if s == nil || s.TooMany == "" {
+		return SanitizeActionDrop
+	}
+
+	return s.TooMany
+}
+
+// GetMode returns the mode of the function.
+func (m *ManagedTags) GetMode() Mode {
+	if m == nil || m.Mode == "" {
+		return ModeApply
+	}
+
+	return m.Mode
+}
+
+// GetDiscovery returns how the function learns whether a kind supports tags.
+func (m *ManagedTags) GetDiscovery() Discovery {
+	if m == nil || m.Discovery == "" {
+		return DiscoveryGenerated
+	}
+
+	return m.Discovery
+}
+
+// GetUnknownKinds returns whether kinds missing from the filters are tagged.
+func (m *ManagedTags) GetUnknownKinds() UnknownKindPolicy {
+	if m == nil || m.UnknownKinds == "" {
+		return UnknownKindsSkip
+	}
+
+	return m.UnknownKinds
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"github.com/alecthomas/kong"
+	"github.com/crossplane-contrib/function-tag-manager/filters"
 	"github.com/crossplane/function-sdk-go"
 )
 
@@ -24,6 +25,15 @@ func (c *CLI) Run() error {
 		return err
 	}
 
+	for _, p := range filters.NewProvenance() {
+		if !p.Recorded() {
+			log.Info("Loaded resource filters generated without provenance", "provider", p.Provider, "source", p.Source)
+			continue
+		}
+
+		log.Info("Loaded resource filters", "provider", p.Provider, "ref", p.Ref, "version", p.Version, "commit", p.Commit, "generatedAt", p.GeneratedAt)
+	}
+
 	return function.Serve(&Function{log: log},
 		function.Listen(c.Network, c.Address),
 		function.MTLSCertificates(c.TLSCertsDir),
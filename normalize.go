@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// NormalizeResourceTags fixes the tags in a tag path of a Desired Composed
+// Resource to the rules of providers that normalize tags, like GCP labels.
+// It returns a description of every tag that was dropped.
+func NormalizeResourceTags(desired *resource.DesiredComposed, p filters.TagPath) ([]string, error) {
+	limits, ok := filters.TagLimitsForGroup(desired.Resource.GroupVersionKind().Group)
+	if !ok || !limits.Normalize {
+		return nil, nil
+	}
+
+	desiredTags, err := GetTags(desired.Resource.Object, p.Desired, p.GetShape())
+	if err != nil || len(desiredTags) == 0 {
+		return nil, nil //nolint:nilerr // A resource without tags has nothing to normalize.
+	}
+
+	normalized, dropped := NormalizeTags(desiredTags, limits)
+	if maps.Equal(desiredTags, normalized) {
+		return dropped, nil
+	}
+
+	return dropped, SetTags(desired, p, normalized)
+}
+
+// NormalizeTags lowercases keys and values, replaces characters that are not
+// valid with an underscore and truncates keys and values to the maximum length.
+// Keys that do not start with a letter, or that collide with another key once
+// normalized, are dropped. It returns the normalized tags and a description
+// of every tag that was dropped.
+func NormalizeTags(tags v1beta1.Tags, limits filters.TagLimits) (v1beta1.Tags, []string) {
+	normalized := make(v1beta1.Tags, len(tags))
+	dropped := make([]string, 0)
+
+	// Keys that are already valid take precedence over keys that normalize to them
+	keys := slices.SortedFunc(maps.Keys(tags), func(a, b string) int {
+		aValid, bValid := normalizeLabel(a, limits.ValidCharacters, limits.MaxKeyLength) == a, normalizeLabel(b, limits.ValidCharacters, limits.MaxKeyLength) == b
+		switch {
+		case aValid && !bValid:
+			return -1
+		case !aValid && bValid:
+			return 1
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	for _, k := range keys {
+		key := normalizeLabel(k, limits.ValidCharacters, limits.MaxKeyLength)
+
+		first, _ := firstRune(key)
+		if !unicode.IsLetter(first) {
+			dropped = append(dropped, fmt.Sprintf("dropped %s label %q that does not start with a letter", limits.Provider, k))
+			continue
+		}
+
+		if _, ok := normalized[key]; ok {
+			dropped = append(dropped, fmt.Sprintf("dropped %s label %q because normalized key %q already exists", limits.Provider, k, key))
+			continue
+		}
+
+		normalized[key] = normalizeLabel(tags[k], limits.ValidCharacters, limits.MaxValueLength)
+	}
+
+	return normalized, dropped
+}
+
+// normalizeLabel lowercases s, replaces characters that are not valid and
+// truncates it to the maximum length.
+func normalizeLabel(s, valid string, maxLength int) string {
+	normalized := strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if valid != "" && !strings.ContainsRune(valid, r) {
+			return '_'
+		}
+
+		return r
+	}, s)
+
+	if maxLength > 0 && len([]rune(normalized)) > maxLength {
+		normalized = string([]rune(normalized)[:maxLength])
+	}
+
+	return normalized
+}
+
+// firstRune returns the first rune of s.
+func firstRune(s string) (rune, bool) {
+	for _, r := range s {
+		return r, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	gcp, _ := filters.TagLimitsForGroup("storage.gcp.upbound.io")
+
+	type want struct {
+		tags    v1beta1.Tags
+		dropped int
+	}
+
+	cases := map[string]struct {
+		reason string
+		tags   v1beta1.Tags
+		want   want
+	}{
+		"ValidLabels": {
+			reason: "Valid labels are not changed",
+			tags:   v1beta1.Tags{"owner": "platform", "cost-center": "1234_a"},
+			want:   want{tags: v1beta1.Tags{"owner": "platform", "cost-center": "1234_a"}},
+		},
+		"Lowercase": {
+			reason: "Keys and values are lowercased",
+			tags:   v1beta1.Tags{"Owner": "Platform"},
+			want:   want{tags: v1beta1.Tags{"owner": "platform"}},
+		},
+		"InvalidCharacters": {
+			reason: "Invalid characters are replaced with an underscore",
+			tags:   v1beta1.Tags{"crossplane.io/claim": "example.crossplane.io/v1"},
+			want:   want{tags: v1beta1.Tags{"crossplane_io_claim": "example_crossplane_io_v1"}},
+		},
+		"TooLong": {
+			reason: "Keys and values are truncated to 63 characters",
+			tags:   v1beta1.Tags{strings.Repeat("k", 70): strings.Repeat("v", 70)},
+			want:   want{tags: v1beta1.Tags{strings.Repeat("k", 63): strings.Repeat("v", 63)}},
+		},
+		"StartsWithLetter": {
+			reason: "Keys that do not start with a letter are dropped",
+			tags:   v1beta1.Tags{"2fa": "enabled", "_internal": "true", "owner": "platform"},
+			want:   want{tags: v1beta1.Tags{"owner": "platform"}, dropped: 2},
+		},
+		"Collision": {
+			reason: "A valid key takes precedence over a key that normalizes to it",
+			tags:   v1beta1.Tags{"Owner": "Team", "owner": "platform"},
+			want:   want{tags: v1beta1.Tags{"owner": "platform"}, dropped: 1},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, dropped := NormalizeTags(tc.tags, gcp)
+
+			if diff := cmp.Diff(tc.want.tags, got); diff != "" {
+				t.Errorf("%s\nNormalizeTags(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			if len(dropped) != tc.want.dropped {
+				t.Errorf("%s\nNormalizeTags(...): want %d dropped, got %d: %v", tc.reason, tc.want.dropped, len(dropped), dropped)
+			}
+		})
+	}
+}
+
+func TestNormalizeResourceTags(t *testing.T) {
+	cases := map[string]struct {
+		reason  string
+		desired map[string]any
+		want    map[string]any
+	}{
+		"GCPLabels": {
+			reason: "The labels of GCP resources are normalized",
+			desired: map[string]any{
+				"apiVersion": "storage.gcp.m.upbound.io/v1beta1",
+				"kind":       "Bucket",
+				"spec":       map[string]any{"forProvider": map[string]any{"labels": map[string]any{"Owner": "Platform"}}},
+			},
+			want: map[string]any{"owner": "platform"},
+		},
+		"AWSTags": {
+			reason: "The tags of providers that do not normalize tags are not changed",
+			desired: map[string]any{
+				"apiVersion": "ec2.aws.upbound.io/v1beta1",
+				"kind":       "VPC",
+				"spec":       map[string]any{"forProvider": map[string]any{"tags": map[string]any{"Owner": "Platform"}}},
+			},
+			want: map[string]any{"Owner": "Platform"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: tc.desired}}}
+			p := ManagedTagPaths(desired, filters.NewTagPaths())[0]
+
+			if _, err := NormalizeResourceTags(desired, p); err != nil {
+				t.Fatalf("%s\nNormalizeResourceTags(...): %v", tc.reason, err)
+			}
+
+			var got map[string]any
+			_ = desired.Resource.GetValueInto(p.Desired, &got)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nNormalizeResourceTags(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
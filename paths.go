@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/fieldpath"
+)
+
+// Fields of the entries of a filters.TagShapeKeyValueList.
+const (
+	fieldTagKey   = "key"
+	fieldTagValue = "value"
+)
+
+// ManagedTagPaths returns the tag paths of a Desired Composed Resource that
+// can be managed. The primary path is always managed. Other paths are only
+// managed when their parent object exists, so that tagging never creates
+// blocks like a root block device that the resource did not declare. Paths
+// next to the primary path, like volumeTags, are only managed when the
+// resource declares them, because they may conflict with other paths.
+func ManagedTagPaths(desired *resource.DesiredComposed, paths filters.TagPaths) []filters.TagPath {
+	all := paths.GetGVK(desired.Resource.GroupVersionKind())
+
+	managed := []filters.TagPath{all[0]}
+
+	for _, p := range all[1:] {
+		if !hasParentObject(desired.Resource.Object, p.Desired) {
+			continue
+		}
+
+		if parentPath(p.Desired) == parentPath(all[0].Desired) && !hasField(desired.Resource.Object, p.Desired) {
+			continue
+		}
+
+		managed = append(managed, p)
+	}
+
+	return managed
+}
+
+// parentPath returns the field path of the parent of a field path.
+func parentPath(fp string) string {
+	segments, err := fieldpath.Parse(fp)
+	if err != nil || len(segments) < 2 {
+		return ""
+	}
+
+	return segments[:len(segments)-1].String()
+}
+
+// hasField returns true if a field path exists in an object.
+func hasField(obj map[string]any, fp string) bool {
+	_, err := fieldpath.Pave(obj).GetValue(fp)
+	return err == nil
+}
+
+// hasParentObject returns true if the parent of a field path is an object.
+func hasParentObject(obj map[string]any, fp string) bool {
+	segments, err := fieldpath.Parse(fp)
+	if err != nil || len(segments) < 2 {
+		return false
+	}
+
+	var parent map[string]any
+
+	return fieldpath.Pave(obj).GetValueInto(segments[:len(segments)-1].String(), &parent) == nil
+}
+
+// TagListDefaults returns the values of extra fields of new entries in tags
+// that are a list of key/value objects.
+func TagListDefaults(in *v1beta1.ManagedTags) (map[string]any, error) {
+	defaults := make(map[string]any, len(in.TagListDefaults))
+
+	for field, raw := range in.TagListDefaults {
+		var v any
+		if err := json.Unmarshal(raw.Raw, &v); err != nil {
+			return nil, errors.Wrapf(err, "cannot parse tagListDefaults field %q", field)
+		}
+
+		defaults[field] = v
+	}
+
+	return defaults, nil
+}
+
+// WithTagListDefaults returns the tag paths with the supplied defaults
+// overriding the defaults of every key/value list.
+func WithTagListDefaults(paths []filters.TagPath, defaults map[string]any) []filters.TagPath {
+	if len(defaults) == 0 {
+		return paths
+	}
+
+	out := make([]filters.TagPath, 0, len(paths))
+
+	for _, p := range paths {
+		if p.GetShape() == filters.TagShapeKeyValueList {
+			merged := maps.Clone(p.Defaults)
+			if merged == nil {
+				merged = make(map[string]any, len(defaults))
+			}
+
+			maps.Copy(merged, defaults)
+			p.Defaults = merged
+		}
+
+		out = append(out, p)
+	}
+
+	return out
+}
+
+// GetTags returns the tags at a field path of an object. Missing or
+// malformed tags return an error. Entries of a key/value list without a
+// string key and value are skipped.
+func GetTags(obj map[string]any, fp string, shape filters.TagShape) (v1beta1.Tags, error) {
+	if shape != filters.TagShapeKeyValueList {
+		var tags v1beta1.Tags
+
+		err := fieldpath.Pave(obj).GetValueInto(fp, &tags)
+
+		return tags, err
+	}
+
+	var list []map[string]any
+	if err := fieldpath.Pave(obj).GetValueInto(fp, &list); err != nil {
+		return nil, err
+	}
+
+	tags := make(v1beta1.Tags, len(list))
+
+	for _, entry := range list {
+		k, kok := entry[fieldTagKey].(string)
+		v, vok := entry[fieldTagValue].(string)
+
+		if kok && vok {
+			tags[k] = v
+		}
+	}
+
+	return tags, nil
+}
+
+// SetTags sets the tags at the desired field path of a Desired Composed Resource.
+// Existing entries of a key/value list keep their extra fields, and new entries
+// are created with the defaults of the tag path.
+func SetTags(desired *resource.DesiredComposed, p filters.TagPath, tags v1beta1.Tags) error {
+	if p.GetShape() != filters.TagShapeKeyValueList {
+		return desired.Resource.SetValue(p.Desired, tags)
+	}
+
+	var existing []map[string]any
+
+	_ = fieldpath.Pave(desired.Resource.Object).GetValueInto(p.Desired, &existing)
+
+	list := make([]any, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+
+	for _, entry := range existing {
+		k, ok := entry[fieldTagKey].(string)
+		if !ok {
+			continue
+		}
+
+		v, ok := tags[k]
+		if !ok || seen[k] {
+			continue
+		}
+
+		entry[fieldTagValue] = v
+		list = append(list, entry)
+		seen[k] = true
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		if seen[k] {
+			continue
+		}
+
+		entry := maps.Clone(p.Defaults)
+		if entry == nil {
+			entry = make(map[string]any, 2)
+		}
+
+		entry[fieldTagKey] = k
+		entry[fieldTagValue] = tags[k]
+		list = append(list, entry)
+	}
+
+	return desired.Resource.SetValue(p.Desired, list)
+}
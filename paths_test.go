@@ -0,0 +1,241 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// instanceTagPaths are the tag paths the generator emits for EC2 Instances,
+// whose root block device is a list in v1beta1 and an object in v1beta2.
+var instanceTagPaths = filters.TagPaths{
+	"ec2.aws.upbound.io/Instance": {
+		filters.DefaultTagPath,
+		{Desired: "spec.forProvider.rootBlockDevice[0].tags", Observed: "status.atProvider.rootBlockDevice[0].tags"},
+		{Desired: "spec.forProvider.volumeTags", Observed: "status.atProvider.volumeTags"},
+	},
+	"ec2.aws.upbound.io/v1beta2/Instance": {
+		filters.DefaultTagPath,
+		{Desired: "spec.forProvider.rootBlockDevice.tags", Observed: "status.atProvider.rootBlockDevice.tags"},
+		{Desired: "spec.forProvider.volumeTags", Observed: "status.atProvider.volumeTags"},
+	},
+}
+
+func TestManagedTagPaths(t *testing.T) {
+	rootBlockDeviceList := filters.TagPath{Desired: "spec.forProvider.rootBlockDevice[0].tags", Observed: "status.atProvider.rootBlockDevice[0].tags"}
+	rootBlockDeviceObject := filters.TagPath{Desired: "spec.forProvider.rootBlockDevice.tags", Observed: "status.atProvider.rootBlockDevice.tags"}
+	volumeTags := filters.TagPath{Desired: "spec.forProvider.volumeTags", Observed: "status.atProvider.volumeTags"}
+
+	cases := map[string]struct {
+		reason  string
+		desired map[string]any
+		want    []filters.TagPath
+	}{
+		"DefaultPath": {
+			reason: "Kinds without tag paths use the default path",
+			desired: map[string]any{
+				"apiVersion": "ec2.aws.upbound.io/v1beta1",
+				"kind":       "VPC",
+			},
+			want: []filters.TagPath{filters.DefaultTagPath},
+		},
+		"ProviderPath": {
+			reason: "GCP kinds use the labels path of their provider family",
+			desired: map[string]any{
+				"apiVersion": "storage.gcp.upbound.io/v1beta1",
+				"kind":       "Bucket",
+			},
+			want: []filters.TagPath{{Desired: "spec.forProvider.labels", Observed: "status.atProvider.labels"}},
+		},
+		"InstanceWithoutRootBlockDevice": {
+			reason: "Nested tag paths are not managed when their parent does not exist",
+			desired: map[string]any{
+				"apiVersion": "ec2.aws.upbound.io/v1beta1",
+				"kind":       "Instance",
+				"spec":       map[string]any{"forProvider": map[string]any{}},
+			},
+			want: []filters.TagPath{filters.DefaultTagPath},
+		},
+		"InstanceRootBlockDeviceList": {
+			reason: "The root block device tags are managed when it is declared as a list",
+			desired: map[string]any{
+				"apiVersion": "ec2.aws.upbound.io/v1beta1",
+				"kind":       "Instance",
+				"spec": map[string]any{"forProvider": map[string]any{
+					"rootBlockDevice": []any{map[string]any{"volumeSize": float64(20)}},
+				}},
+			},
+			want: []filters.TagPath{filters.DefaultTagPath, rootBlockDeviceList},
+		},
+		"InstanceRootBlockDeviceObject": {
+			reason: "The root block device tags are managed when it is declared as an object",
+			desired: map[string]any{
+				"apiVersion": "ec2.aws.upbound.io/v1beta2",
+				"kind":       "Instance",
+				"spec": map[string]any{"forProvider": map[string]any{
+					"rootBlockDevice": map[string]any{"volumeSize": float64(20)},
+				}},
+			},
+			want: []filters.TagPath{filters.DefaultTagPath, rootBlockDeviceObject},
+		},
+		"InstanceVolumeTags": {
+			reason: "Tag fields next to the primary tags are managed when they are declared",
+			desired: map[string]any{
+				"apiVersion": "ec2.aws.upbound.io/v1beta1",
+				"kind":       "Instance",
+				"spec": map[string]any{"forProvider": map[string]any{
+					"volumeTags": map[string]any{},
+				}},
+			},
+			want: []filters.TagPath{filters.DefaultTagPath, volumeTags},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: tc.desired}}}
+
+			paths := filters.NewTagPaths()
+			for k, v := range instanceTagPaths {
+				paths[k] = v
+			}
+
+			got := ManagedTagPaths(desired, paths)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nManagedTagPaths(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMergeTagsNestedPath(t *testing.T) {
+	desired := &resource.DesiredComposed{Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "ec2.aws.upbound.io/v1beta1",
+		"kind":       "Instance",
+		"spec": map[string]any{"forProvider": map[string]any{
+			"rootBlockDevice": []any{map[string]any{"volumeSize": float64(20)}},
+			"tags":            map[string]any{"Name": "instance"},
+		}},
+	}}}}
+
+	tu := TagUpdater{Replace: v1beta1.Tags{"owner": "platform"}}
+
+	for _, p := range ManagedTagPaths(desired, instanceTagPaths) {
+		if err := MergeTags(desired, tu, p); err != nil {
+			t.Fatalf("MergeTags(...): %v", err)
+		}
+	}
+
+	want := map[string]any{
+		"rootBlockDevice": []any{map[string]any{"volumeSize": int64(20), "tags": map[string]any{"owner": "platform"}}},
+		"tags":            map[string]any{"Name": "instance", "owner": "platform"},
+	}
+
+	var got map[string]any
+	if err := desired.Resource.GetValueInto("spec.forProvider", &got); err != nil {
+		t.Fatalf("GetValueInto(...): %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MergeTags(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestSetTagsKeyValueList(t *testing.T) {
+	p := filters.NewTagPaths().Get("autoscaling.aws.upbound.io/AutoscalingGroup")[0]
+
+	cases := map[string]struct {
+		reason   string
+		existing []any
+		tags     v1beta1.Tags
+		want     []any
+	}{
+		"NewList": {
+			reason: "New entries are sorted by key and use the defaults of the tag path",
+			tags:   v1beta1.Tags{"owner": "platform", "env": "dev"},
+			want: []any{
+				map[string]any{"key": "env", "value": "dev", "propagateAtLaunch": true},
+				map[string]any{"key": "owner", "value": "platform", "propagateAtLaunch": true},
+			},
+		},
+		"ExistingList": {
+			reason: "Existing entries keep their order and extra fields, and entries without a tag are removed",
+			existing: []any{
+				map[string]any{"key": "owner", "value": "team", "propagateAtLaunch": false},
+				map[string]any{"key": "old", "value": "value", "propagateAtLaunch": true},
+			},
+			tags: v1beta1.Tags{"owner": "platform", "env": "dev"},
+			want: []any{
+				map[string]any{"key": "owner", "value": "platform", "propagateAtLaunch": false},
+				map[string]any{"key": "env", "value": "dev", "propagateAtLaunch": true},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			forProvider := map[string]any{}
+			if tc.existing != nil {
+				forProvider["tag"] = tc.existing
+			}
+
+			desired := &resource.DesiredComposed{Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+				"spec": map[string]any{"forProvider": forProvider},
+			}}}}
+
+			if err := SetTags(desired, p, tc.tags); err != nil {
+				t.Fatalf("%s\nSetTags(...): %v", tc.reason, err)
+			}
+
+			var got []any
+			_ = desired.Resource.GetValueInto(p.Desired, &got)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nSetTags(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			tags, err := GetTags(desired.Resource.Object, p.Desired, p.GetShape())
+			if err != nil {
+				t.Fatalf("%s\nGetTags(...): %v", tc.reason, err)
+			}
+
+			if diff := cmp.Diff(tc.tags, tags); diff != "" {
+				t.Errorf("%s\nGetTags(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWithTagListDefaults(t *testing.T) {
+	in := &v1beta1.ManagedTags{TagListDefaults: map[string]extv1.JSON{"propagateAtLaunch": {Raw: []byte("false")}}}
+
+	defaults, err := TagListDefaults(in)
+	if err != nil {
+		t.Fatalf("TagListDefaults(...): %v", err)
+	}
+
+	paths := []filters.TagPath{
+		filters.DefaultTagPath,
+		filters.NewTagPaths().Get("autoscaling.aws.upbound.io/AutoscalingGroup")[0],
+	}
+
+	got := WithTagListDefaults(paths, defaults)
+
+	if got[0].Defaults != nil {
+		t.Errorf("WithTagListDefaults(...): want no defaults for map tags, got %v", got[0].Defaults)
+	}
+
+	if diff := cmp.Diff(map[string]any{"propagateAtLaunch": false}, got[1].Defaults); diff != "" {
+		t.Errorf("WithTagListDefaults(...): -want, +got:\n%s", diff)
+	}
+
+	if paths[1].Defaults["propagateAtLaunch"] != true {
+		t.Errorf("WithTagListDefaults(...): defaults of the filter were changed")
+	}
+}
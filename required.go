@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// MissingTags returns the keys that are not set in a tag path of a
+// Desired Composed Resource.
+func MissingTags(desired *resource.DesiredComposed, keys []string, p filters.TagPath) []string {
+	desiredTags, _ := GetTags(desired.Resource.Object, p.Desired, p.GetShape())
+
+	missing := make([]string, 0)
+
+	for _, k := range keys {
+		if _, ok := desiredTags[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+
+	return missing
+}
+
+// FormatMissingTags returns a sorted, human-readable summary of the
+// missing tag keys of each resource.
+func FormatMissingTags(missing map[resource.Name][]string) string {
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, string(name))
+	}
+
+	slices.Sort(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s (%s)", name, strings.Join(missing[resource.Name(name)], ", ")))
+	}
+
+	return strings.Join(parts, "; ")
+}
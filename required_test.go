@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestMissingTags(t *testing.T) {
+	type args struct {
+		desired *resource.DesiredComposed
+		keys    []string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []string
+	}{
+		"NoTags": {
+			reason: "A resource without tags is missing every key",
+			args: args{
+				desired: &resource.DesiredComposed{
+					Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+						Object: map[string]any{
+							"spec": map[string]any{
+								"forProvider": map[string]any{},
+							},
+						},
+					}},
+				},
+				keys: []string{"owner", "cost-center"},
+			},
+			want: []string{"owner", "cost-center"},
+		},
+		"SomeTags": {
+			reason: "Only keys that are not set are returned, even if the value is empty",
+			args: args{
+				desired: &resource.DesiredComposed{
+					Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+						Object: map[string]any{
+							"spec": map[string]any{
+								"forProvider": map[string]any{
+									"tags": map[string]any{
+										"owner":       "platform",
+										"environment": "",
+									},
+								},
+							},
+						},
+					}},
+				},
+				keys: []string{"owner", "cost-center", "environment"},
+			},
+			want: []string{"cost-center"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := MissingTags(tc.args.desired, tc.args.keys, filters.DefaultTagPath)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nMissingTags(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFormatMissingTags(t *testing.T) {
+	missing := map[resource.Name][]string{
+		"vpc":    {"owner", "cost-center"},
+		"subnet": {"owner"},
+	}
+
+	want := "subnet (owner); vpc (owner, cost-center)"
+	if got := FormatMissingTags(missing); got != want {
+		t.Errorf("FormatMissingTags(...): want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+)
+
+// TagRule is a tag rule with its compiled pattern.
+type TagRule struct {
+	v1beta1.TagRule
+
+	pattern *regexp.Regexp
+}
+
+// CompileTagRules validates tag rules and compiles their patterns. A rule
+// that can never be applied, like a pattern that is not a valid regular
+// expression, is an error.
+func CompileTagRules(rules []v1beta1.TagRule) ([]TagRule, error) {
+	compiled := make([]TagRule, 0, len(rules))
+
+	for _, rule := range rules {
+		r := TagRule{TagRule: rule}
+
+		if rule.Pattern != nil {
+			re, err := compilePattern(*rule.Pattern)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot compile pattern %q of tag rule for %q", *rule.Pattern, rule.Key)
+			}
+
+			r.pattern = re
+		}
+
+		if rule.GetAction() == v1beta1.TagRuleActionUseDefault && rule.Default == nil {
+			return nil, errors.Errorf("tag rule for %q has action %s but no default", rule.Key, v1beta1.TagRuleActionUseDefault)
+		}
+
+		compiled = append(compiled, r)
+	}
+
+	return compiled, nil
+}
+
+// repetition matches a complete repetition like {4}, {2,} or {2,4}.
+var repetition = regexp.MustCompile(`^\{[0-9]+(,[0-9]*)?\}`)
+
+// compilePattern compiles the pattern of a tag rule. Go treats a brace that
+// does not start a complete repetition as a literal, so a typo like {4$
+// would silently never match. Such braces are rejected, literal braces must
+// be escaped.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	inClass := false
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+			// Skip the braces of escapes like \p{Greek} and \x{41}
+			if i+1 < len(pattern) && strings.ContainsRune("pPx", rune(pattern[i])) && pattern[i+1] == '{' {
+				if end := strings.IndexByte(pattern[i:], '}'); end >= 0 {
+					i += end
+				}
+			}
+		case inClass && strings.HasPrefix(pattern[i:], "[:"):
+			if end := strings.Index(pattern[i+2:], ":]"); end >= 0 {
+				i += end + 3
+			}
+		case inClass && c == ']':
+			inClass = false
+		case c == '[':
+			inClass = true
+			// A ] right after [ or [^ is a literal
+			if strings.HasPrefix(pattern[i+1:], "^]") {
+				i += 2
+			} else if strings.HasPrefix(pattern[i+1:], "]") {
+				i++
+			}
+		case !inClass && c == '{' && !repetition.MatchString(pattern[i:]):
+			return nil, errors.Errorf("incomplete repetition at offset %d, escape literal braces with \\{", i)
+		}
+	}
+
+	return re, nil
+}
+
+// ApplyTagRules validates tag values against rules. Invalid tags are dropped,
+// set to the rule default or returned as an error depending on the rule action.
+// It returns a description of every dropped or replaced value.
+func ApplyTagRules(tags v1beta1.Tags, rules []TagRule) (v1beta1.Tags, []string, error) {
+	if len(rules) == 0 || len(tags) == 0 {
+		return tags, nil, nil
+	}
+
+	valid := maps.Clone(tags)
+	changes := make([]string, 0)
+
+	for _, rule := range rules {
+		v, ok := valid[rule.Key]
+		if !ok {
+			continue
+		}
+
+		err := CheckTagRule(rule, v)
+		if err == nil {
+			continue
+		}
+
+		switch rule.GetAction() {
+		case v1beta1.TagRuleActionFail:
+			return nil, nil, errors.Wrapf(err, "invalid value %q for tag %q", v, rule.Key)
+		case v1beta1.TagRuleActionUseDefault:
+			valid[rule.Key] = *rule.Default
+			changes = append(changes, fmt.Sprintf("replaced invalid value %q of tag %q with %q: %v", v, rule.Key, *rule.Default, err))
+		case v1beta1.TagRuleActionDrop:
+			delete(valid, rule.Key)
+			changes = append(changes, fmt.Sprintf("dropped invalid value %q of tag %q: %v", v, rule.Key, err))
+		}
+	}
+
+	return valid, changes, nil
+}
+
+// CheckTagRule returns an error if a value breaks a rule.
+func CheckTagRule(rule TagRule, value string) error {
+	if len(rule.AllowedValues) > 0 && !slices.Contains(rule.AllowedValues, value) {
+		return errors.Errorf("value must be one of %v", rule.AllowedValues)
+	}
+
+	length := utf8.RuneCountInString(value)
+	if rule.MinLength != nil && length < *rule.MinLength {
+		return errors.Errorf("value must be at least %d characters", *rule.MinLength)
+	}
+
+	if rule.MaxLength != nil && length > *rule.MaxLength {
+		return errors.Errorf("value must be at most %d characters", *rule.MaxLength)
+	}
+
+	if rule.pattern != nil && !rule.pattern.MatchString(value) {
+		return errors.Errorf("value must match %q", rule.pattern.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/utils/ptr"
+)
+
+func TestApplyTagRules(t *testing.T) {
+	type args struct {
+		tags  v1beta1.Tags
+		rules []v1beta1.TagRule
+	}
+
+	type want struct {
+		tags    v1beta1.Tags
+		changes []string
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoRules": {
+			reason: "Tags are returned unchanged without rules",
+			args: args{
+				tags: v1beta1.Tags{"environment": "qa"},
+			},
+			want: want{tags: v1beta1.Tags{"environment": "qa"}},
+		},
+		"ValidValues": {
+			reason: "Valid values are kept",
+			args: args{
+				tags: v1beta1.Tags{"environment": "prod", "cost-center": "CC-1234"},
+				rules: []v1beta1.TagRule{
+					{Key: "environment", AllowedValues: []string{"dev", "stage", "prod"}},
+					{Key: "cost-center", Pattern: ptr.To("^CC-[0-9]{4}$"), MaxLength: ptr.To(7)},
+				},
+			},
+			want: want{tags: v1beta1.Tags{"environment": "prod", "cost-center": "CC-1234"}},
+		},
+		"DropInvalid": {
+			reason: "Invalid values are dropped by default",
+			args: args{
+				tags: v1beta1.Tags{"environment": "qa", "owner": "platform"},
+				rules: []v1beta1.TagRule{
+					{Key: "environment", AllowedValues: []string{"dev", "stage", "prod"}},
+				},
+			},
+			want: want{
+				tags:    v1beta1.Tags{"owner": "platform"},
+				changes: []string{`dropped invalid value "qa" of tag "environment": value must be one of [dev stage prod]`},
+			},
+		},
+		"UseDefault": {
+			reason: "Invalid values are replaced with the rule default",
+			args: args{
+				tags: v1beta1.Tags{"owner": "a"},
+				rules: []v1beta1.TagRule{
+					{Key: "owner", MinLength: ptr.To(2), Action: v1beta1.TagRuleActionUseDefault, Default: ptr.To("platform")},
+				},
+			},
+			want: want{
+				tags:    v1beta1.Tags{"owner": "platform"},
+				changes: []string{`replaced invalid value "a" of tag "owner" with "platform": value must be at least 2 characters`},
+			},
+		},
+		"Fail": {
+			reason: "A Fail rule returns an error for invalid values",
+			args: args{
+				tags: v1beta1.Tags{"cost-center": "1234"},
+				rules: []v1beta1.TagRule{
+					{Key: "cost-center", Pattern: ptr.To("^CC-[0-9]{4}$"), Action: v1beta1.TagRuleActionFail},
+				},
+			},
+			want: want{err: cmpopts.AnyError},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rules, err := CompileTagRules(tc.args.rules)
+			if err != nil {
+				t.Fatalf("%s\nCompileTagRules(...): %v", tc.reason, err)
+			}
+
+			got, changes, err := ApplyTagRules(tc.args.tags, rules)
+
+			if diff := cmp.Diff(tc.want.tags, got); diff != "" {
+				t.Errorf("%s\nApplyTagRules(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.changes, changes, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%s\nApplyTagRules(...): -want changes, +got changes:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("%s\nApplyTagRules(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCompileTagRules(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		rules  []v1beta1.TagRule
+		want   error
+	}{
+		"ValidRules": {
+			reason: "Rules with valid patterns and defaults compile",
+			rules: []v1beta1.TagRule{
+				{Key: "cost-center", Pattern: ptr.To("^CC-[0-9]{4}$"), Action: v1beta1.TagRuleActionDrop},
+				{Key: "owner", MinLength: ptr.To(2), Action: v1beta1.TagRuleActionUseDefault, Default: ptr.To("platform")},
+			},
+		},
+		"InvalidPattern": {
+			reason: "A pattern that is not a valid regular expression is an error, instead of dropping every value",
+			rules: []v1beta1.TagRule{
+				{Key: "cost-center", Pattern: ptr.To("^CC-[0-9]{4$"), Action: v1beta1.TagRuleActionDrop},
+			},
+			want: cmpopts.AnyError,
+		},
+		"UnclosedClass": {
+			reason: "A pattern with an unclosed character class is an error",
+			rules: []v1beta1.TagRule{
+				{Key: "cost-center", Pattern: ptr.To("^CC-[0-9"), Action: v1beta1.TagRuleActionDrop},
+			},
+			want: cmpopts.AnyError,
+		},
+		"LiteralBraces": {
+			reason: "Escaped braces, braces in character classes and braces of escapes are not repetitions",
+			rules: []v1beta1.TagRule{
+				{Key: "template", Pattern: ptr.To(`^\{[a-z]+\}$`)},
+				{Key: "brace", Pattern: ptr.To(`^[{}[:alpha:]]+$`)},
+				{Key: "bracket", Pattern: ptr.To(`^[]{]+$`)},
+				{Key: "escape", Pattern: ptr.To(`^\p{Lu}\x{41}{2}$`)},
+			},
+		},
+		"UseDefaultWithoutDefault": {
+			reason: "A UseDefault rule without a default is an error",
+			rules: []v1beta1.TagRule{
+				{Key: "owner", MinLength: ptr.To(2), Action: v1beta1.TagRuleActionUseDefault},
+			},
+			want: cmpopts.AnyError,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := CompileTagRules(tc.rules)
+
+			if diff := cmp.Diff(tc.want, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("%s\nCompileTagRules(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
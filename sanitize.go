@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+)
+
+// SanitizeResourceTags fixes the tags in a tag path of a Desired Composed Resource
+// that break the limits of its provider family. It returns a description of every
+// change. Resources of unknown provider families are not changed.
+func SanitizeResourceTags(desired *resource.DesiredComposed, in *v1beta1.Sanitize, p filters.TagPath) ([]string, error) {
+	limits, ok := filters.TagLimitsForGroup(desired.Resource.GroupVersionKind().Group)
+	if !ok {
+		return nil, nil
+	}
+
+	desiredTags, err := GetTags(desired.Resource.Object, p.Desired, p.GetShape())
+	if err != nil || len(desiredTags) == 0 {
+		return nil, nil //nolint:nilerr // A resource without tags has nothing to sanitize.
+	}
+
+	sanitized, changes, err := SanitizeTags(desiredTags, limits, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	return changes, SetTags(desired, p, sanitized)
+}
+
+// SanitizeTags fixes tags that break provider limits. It returns the sanitized
+// tags and a description of every change.
+func SanitizeTags(tags v1beta1.Tags, limits filters.TagLimits, in *v1beta1.Sanitize) (v1beta1.Tags, []string, error) {
+	sanitized := make(v1beta1.Tags, len(tags))
+	changes := make([]string, 0)
+
+	// Process keys in order so that changes are reported consistently
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		key, value := k, tags[k]
+
+		if prefix, ok := reservedPrefix(key, limits.ReservedPrefixes); ok {
+			if in.GetReservedPrefix() == v1beta1.SanitizeActionFail {
+				return nil, nil, errors.Errorf("%s tag key %q uses reserved prefix %q", limits.Provider, key, prefix)
+			}
+
+			changes = append(changes, fmt.Sprintf("dropped tag %q with reserved prefix %q", key, prefix))
+
+			continue
+		}
+
+		if limits.InvalidKeyCharacters != "" && strings.ContainsAny(key, limits.InvalidKeyCharacters) {
+			switch in.GetInvalidCharacters() {
+			case v1beta1.SanitizeActionFail:
+				return nil, nil, errors.Errorf("%s tag key %q contains invalid characters %q", limits.Provider, key, limits.InvalidKeyCharacters)
+			case v1beta1.SanitizeActionDrop:
+				changes = append(changes, fmt.Sprintf("dropped tag %q with invalid characters", key))
+				continue
+			default:
+				key = replaceAny(key, limits.InvalidKeyCharacters, in.GetReplacement())
+				changes = append(changes, fmt.Sprintf("replaced invalid characters in tag key %q with %q", k, key))
+			}
+		}
+
+		if limits.MaxKeyLength > 0 && len([]rune(key)) > limits.MaxKeyLength {
+			switch in.GetTooLong() {
+			case v1beta1.SanitizeActionFail:
+				return nil, nil, errors.Errorf("%s tag key %q is longer than %d characters", limits.Provider, key, limits.MaxKeyLength)
+			case v1beta1.SanitizeActionDrop:
+				changes = append(changes, fmt.Sprintf("dropped tag %q with key longer than %d characters", k, limits.MaxKeyLength))
+				continue
+			default:
+				key = string([]rune(key)[:limits.MaxKeyLength])
+				changes = append(changes, fmt.Sprintf("truncated tag key %q to %d characters", k, limits.MaxKeyLength))
+			}
+		}
+
+		if limits.MaxValueLength > 0 && len([]rune(value)) > limits.MaxValueLength {
+			switch in.GetTooLong() {
+			case v1beta1.SanitizeActionFail:
+				return nil, nil, errors.Errorf("%s tag %q has a value longer than %d characters", limits.Provider, k, limits.MaxValueLength)
+			case v1beta1.SanitizeActionDrop:
+				changes = append(changes, fmt.Sprintf("dropped tag %q with value longer than %d characters", k, limits.MaxValueLength))
+				continue
+			default:
+				value = string([]rune(value)[:limits.MaxValueLength])
+				changes = append(changes, fmt.Sprintf("truncated value of tag %q to %d characters", k, limits.MaxValueLength))
+			}
+		}
+
+		// A sanitized key must not overwrite another tag
+		_, sanitizedExists := sanitized[key]
+		_, originalExists := tags[key]
+
+		if sanitizedExists || (key != k && originalExists) {
+			changes = append(changes, fmt.Sprintf("dropped tag %q because sanitized key %q already exists", k, key))
+			continue
+		}
+
+		sanitized[key] = value
+	}
+
+	if limits.MaxTags > 0 && len(sanitized) > limits.MaxTags {
+		if in.GetTooMany() == v1beta1.SanitizeActionFail {
+			return nil, nil, errors.Errorf("%d tags is more than the %s limit of %d", len(sanitized), limits.Provider, limits.MaxTags)
+		}
+
+		keys := slices.Sorted(maps.Keys(sanitized))
+		for _, k := range keys[limits.MaxTags:] {
+			delete(sanitized, k)
+			changes = append(changes, fmt.Sprintf("dropped tag %q over the limit of %d tags", k, limits.MaxTags))
+		}
+	}
+
+	return sanitized, changes, nil
+}
+
+// reservedPrefix returns the reserved prefix a key starts with.
+func reservedPrefix(key string, prefixes []string) (string, bool) {
+	for _, p := range prefixes {
+		if strings.HasPrefix(strings.ToLower(key), strings.ToLower(p)) {
+			return p, true
+		}
+	}
+
+	return "", false
+}
+
+// replaceAny replaces every character of chars in s.
+func replaceAny(s, chars, replacement string) string {
+	pairs := make([]string, 0, 2*len(chars))
+	for _, c := range chars {
+		pairs = append(pairs, string(c), replacement)
+	}
+
+	return strings.NewReplacer(pairs...).Replace(s)
+}
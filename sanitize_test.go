@@ -0,0 +1,157 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/filters"
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/utils/ptr"
+)
+
+func TestTagLimitsForGroup(t *testing.T) {
+	cases := map[string]struct {
+		group    string
+		provider string
+		ok       bool
+	}{
+		"AWSCluster":      {group: "ec2.aws.upbound.io", provider: "aws", ok: true},
+		"AWSNamespaced":   {group: "ec2.aws.m.upbound.io", provider: "aws", ok: true},
+		"AzureCluster":    {group: "network.azure.upbound.io", provider: "azure", ok: true},
+		"AzureNamespaced": {group: "network.azure.m.upbound.io", provider: "azure", ok: true},
+		"GCPCluster":      {group: "storage.gcp.upbound.io", provider: "gcp", ok: true},
+		"GCPNamespaced":   {group: "storage.gcp.m.upbound.io", provider: "gcp", ok: true},
+		"Unknown":         {group: "example.crossplane.io", ok: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, ok := filters.TagLimitsForGroup(tc.group)
+			if ok != tc.ok || got.Provider != tc.provider {
+				t.Errorf("TagLimitsForGroup(%q): want %q, %t, got %q, %t", tc.group, tc.provider, tc.ok, got.Provider, ok)
+			}
+		})
+	}
+}
+
+func TestSanitizeTags(t *testing.T) {
+	aws, _ := filters.TagLimitsForGroup("ec2.aws.upbound.io")
+	azure, _ := filters.TagLimitsForGroup("network.azure.upbound.io")
+
+	longKey := strings.Repeat("k", 130)
+	longValue := strings.Repeat("v", 300)
+
+	type args struct {
+		tags   v1beta1.Tags
+		limits filters.TagLimits
+		in     *v1beta1.Sanitize
+	}
+
+	type want struct {
+		tags    v1beta1.Tags
+		changes int
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ValidTags": {
+			reason: "Tags within limits are not changed",
+			args: args{
+				tags:   v1beta1.Tags{"owner": "platform"},
+				limits: aws,
+				in:     &v1beta1.Sanitize{},
+			},
+			want: want{tags: v1beta1.Tags{"owner": "platform"}},
+		},
+		"AWSDefaults": {
+			reason: "By default long keys and values are truncated and reserved prefixes dropped",
+			args: args{
+				tags:   v1beta1.Tags{longKey: longValue, "AWS:cloudformation": "stack", "owner": "platform"},
+				limits: aws,
+				in:     &v1beta1.Sanitize{},
+			},
+			want: want{
+				tags:    v1beta1.Tags{longKey[:128]: longValue[:256], "owner": "platform"},
+				changes: 3,
+			},
+		},
+		"AWSDropTooLong": {
+			reason: "Long tags are dropped with the Drop action",
+			args: args{
+				tags:   v1beta1.Tags{"owner": longValue},
+				limits: aws,
+				in:     &v1beta1.Sanitize{TooLong: v1beta1.SanitizeActionDrop},
+			},
+			want: want{tags: v1beta1.Tags{}, changes: 1},
+		},
+		"AWSFailReserved": {
+			reason: "Reserved prefixes fail with the Fail action",
+			args: args{
+				tags:   v1beta1.Tags{"aws:owner": "platform"},
+				limits: aws,
+				in:     &v1beta1.Sanitize{ReservedPrefix: v1beta1.SanitizeActionFail},
+			},
+			want: want{err: cmpopts.AnyError},
+		},
+		"AzureReplace": {
+			reason: "Invalid Azure key characters are replaced",
+			args: args{
+				tags:   v1beta1.Tags{"team/owner": "platform", "a<b>": "c"},
+				limits: azure,
+				in:     &v1beta1.Sanitize{Replacement: ptr.To("-")},
+			},
+			want: want{tags: v1beta1.Tags{"team-owner": "platform", "a-b-": "c"}, changes: 2},
+		},
+		"AzureReplaceCollision": {
+			reason: "A replaced key does not overwrite an existing tag",
+			args: args{
+				tags:   v1beta1.Tags{"team/owner": "platform", "team_owner": "existing"},
+				limits: azure,
+				in:     &v1beta1.Sanitize{},
+			},
+			want: want{tags: v1beta1.Tags{"team_owner": "existing"}, changes: 2},
+		},
+		"TooMany": {
+			reason: "Tags over the limit are dropped in key order",
+			args: args{
+				tags:   v1beta1.Tags{"a": "1", "b": "2", "c": "3"},
+				limits: filters.TagLimits{MaxTags: 2},
+				in:     &v1beta1.Sanitize{},
+			},
+			want: want{tags: v1beta1.Tags{"a": "1", "b": "2"}, changes: 1},
+		},
+		"TooManyFail": {
+			reason: "Too many tags fail with the Fail action",
+			args: args{
+				tags:   v1beta1.Tags{"a": "1", "b": "2", "c": "3"},
+				limits: filters.TagLimits{MaxTags: 2},
+				in:     &v1beta1.Sanitize{TooMany: v1beta1.SanitizeActionFail},
+			},
+			want: want{err: cmpopts.AnyError},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, changes, err := SanitizeTags(tc.args.tags, tc.args.limits, tc.args.in)
+
+			if diff := cmp.Diff(tc.want.tags, got); diff != "" {
+				t.Errorf("%s\nSanitizeTags(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			if len(changes) != tc.want.changes {
+				t.Errorf("%s\nSanitizeTags(...): want %d changes, got %d: %v", tc.reason, tc.want.changes, len(changes), changes)
+			}
+
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("%s\nSanitizeTags(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"path"
+
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// SelectResource returns true if a desired composed resource matches the selector.
+// A nil selector matches every resource.
+func SelectResource(sel *v1beta1.ResourceSelector, name resource.Name, desired *resource.DesiredComposed) bool {
+	if sel == nil {
+		return true
+	}
+
+	if desired == nil {
+		return false
+	}
+
+	gvk := desired.Resource.GroupVersionKind()
+
+	if len(sel.Names) > 0 && !matchAny(sel.Names, string(name)) {
+		return false
+	}
+
+	if len(sel.APIGroups) > 0 && !matchAny(sel.APIGroups, gvk.Group) {
+		return false
+	}
+
+	if len(sel.Kinds) > 0 && !matchAny(sel.Kinds, gvk.Kind) {
+		return false
+	}
+
+	labels := desired.Resource.GetLabels()
+	for k, v := range sel.MatchLabels {
+		if val, ok := labels[k]; !ok || val != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SelectAddTags returns the AddTags that apply to a desired composed resource.
+func SelectAddTags(in []v1beta1.AddTag, name resource.Name, desired *resource.DesiredComposed) []v1beta1.AddTag {
+	selected := make([]v1beta1.AddTag, 0, len(in))
+
+	for _, at := range in {
+		if SelectResource(at.ResourceSelector, name, desired) {
+			selected = append(selected, at)
+		}
+	}
+
+	return selected
+}
+
+// SelectIgnoreTags returns the IgnoreTags that apply to a desired composed resource.
+func SelectIgnoreTags(in []v1beta1.IgnoreTag, name resource.Name, desired *resource.DesiredComposed) []v1beta1.IgnoreTag {
+	selected := make([]v1beta1.IgnoreTag, 0, len(in))
+
+	for _, it := range in {
+		if SelectResource(it.ResourceSelector, name, desired) {
+			selected = append(selected, it)
+		}
+	}
+
+	return selected
+}
+
+// SelectRemoveTags returns the RemoveTags that apply to a desired composed resource.
+func SelectRemoveTags(in []v1beta1.RemoveTag, name resource.Name, desired *resource.DesiredComposed) []v1beta1.RemoveTag {
+	selected := make([]v1beta1.RemoveTag, 0, len(in))
+
+	for _, rt := range in {
+		if SelectResource(rt.ResourceSelector, name, desired) {
+			selected = append(selected, rt)
+		}
+	}
+
+	return selected
+}
+
+// matchAny returns true if the value matches any of the exact or glob patterns.
+func matchAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if p == value {
+			return true
+		}
+
+		if ok, err := path.Match(p, value); err == nil && ok {
+			return true
+		}
+	}
+
+	return false
+}
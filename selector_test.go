@@ -0,0 +1,126 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSelectResource(t *testing.T) {
+	vpc := &resource.DesiredComposed{
+		Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+			Object: map[string]any{
+				"apiVersion": "ec2.aws.upbound.io/v1beta1",
+				"kind":       "VPC",
+				"metadata": map[string]any{
+					"labels": map[string]any{
+						"tier": "network",
+					},
+				},
+			},
+		}},
+	}
+
+	type args struct {
+		sel     *v1beta1.ResourceSelector
+		name    resource.Name
+		desired *resource.DesiredComposed
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   bool
+	}{
+		"NilSelector": {
+			reason: "A nil selector matches every resource",
+			args:   args{name: "vpc", desired: vpc},
+			want:   true,
+		},
+		"NilResource": {
+			reason: "A selector never matches a nil resource",
+			args:   args{sel: &v1beta1.ResourceSelector{}, name: "vpc"},
+			want:   false,
+		},
+		"ExactName": {
+			reason: "An exact composition resource name matches",
+			args:   args{sel: &v1beta1.ResourceSelector{Names: []string{"vpc"}}, name: "vpc", desired: vpc},
+			want:   true,
+		},
+		"GlobName": {
+			reason: "A glob composition resource name matches",
+			args:   args{sel: &v1beta1.ResourceSelector{Names: []string{"subnet-*", "v*"}}, name: "vpc", desired: vpc},
+			want:   true,
+		},
+		"NameMismatch": {
+			reason: "A different composition resource name does not match",
+			args:   args{sel: &v1beta1.ResourceSelector{Names: []string{"subnet-*"}}, name: "vpc", desired: vpc},
+			want:   false,
+		},
+		"APIGroupGlob": {
+			reason: "A glob API group matches",
+			args:   args{sel: &v1beta1.ResourceSelector{APIGroups: []string{"*.aws.upbound.io"}}, name: "vpc", desired: vpc},
+			want:   true,
+		},
+		"APIGroupMismatch": {
+			reason: "A different API group does not match",
+			args:   args{sel: &v1beta1.ResourceSelector{APIGroups: []string{"network.azure.upbound.io"}}, name: "vpc", desired: vpc},
+			want:   false,
+		},
+		"KindAndLabels": {
+			reason: "A kind and matching labels match",
+			args: args{
+				sel:     &v1beta1.ResourceSelector{Kinds: []string{"VPC"}, MatchLabels: map[string]string{"tier": "network"}},
+				name:    "vpc",
+				desired: vpc,
+			},
+			want: true,
+		},
+		"LabelMismatch": {
+			reason: "All fields must match, so a label mismatch does not match",
+			args: args{
+				sel:     &v1beta1.ResourceSelector{Kinds: []string{"VPC"}, MatchLabels: map[string]string{"tier": "compute"}},
+				name:    "vpc",
+				desired: vpc,
+			},
+			want: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := SelectResource(tc.args.sel, tc.args.name, tc.args.desired)
+			if got != tc.want {
+				t.Errorf("%s\nSelectResource(...): -want: %t, +got: %t", tc.reason, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSelectAddTags(t *testing.T) {
+	desired := &resource.DesiredComposed{
+		Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+			Object: map[string]any{
+				"apiVersion": "ec2.aws.upbound.io/v1beta1",
+				"kind":       "Instance",
+			},
+		}},
+	}
+
+	in := []v1beta1.AddTag{
+		{Tags: v1beta1.Tags{"all": "resources"}},
+		{Tags: v1beta1.Tags{"backup-plan": "daily"}, ResourceSelector: &v1beta1.ResourceSelector{APIGroups: []string{"ec2.aws.upbound.io"}}},
+		{Tags: v1beta1.Tags{"Name": "vpc"}, ResourceSelector: &v1beta1.ResourceSelector{Names: []string{"vpc"}}},
+	}
+
+	want := []v1beta1.AddTag{in[0], in[1]}
+
+	got := SelectAddTags(in, "instance", desired)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SelectAddTags(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/response"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/fieldpath"
+)
+
+// SourceError is an error reading tags or tag keys from a source.
+type SourceError struct {
+	// Type of the source.
+	Type v1beta1.TagManagerType
+	// FieldPath of the source, if any.
+	FieldPath string
+	// Required sources fail the function when they cannot be read.
+	Required bool
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the error message.
+func (e SourceError) Error() string {
+	if e.FieldPath == "" {
+		return fmt.Sprintf("cannot resolve %s source: %v", e.Type, e.Err)
+	}
+
+	return fmt.Sprintf("cannot resolve %s source %q: %v", e.Type, e.FieldPath, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e SourceError) Unwrap() error {
+	return e.Err
+}
+
+// readSource reads the value of a FromCompositeFieldPath or FromEnvironmentFieldPath
+// source into the supplied value. A field that does not exist is only an error if the
+// source is required.
+func readSource(t v1beta1.TagManagerType, fp *string, required bool, oxr *resource.Composite, env *unstructured.Unstructured, into any) *SourceError {
+	if fp == nil {
+		return &SourceError{Type: t, Required: required, Err: errors.New("fromFieldPath is not set")}
+	}
+
+	var from map[string]any
+
+	switch t {
+	case v1beta1.FromCompositeFieldPath:
+		if oxr == nil || oxr.Resource == nil {
+			return &SourceError{Type: t, FieldPath: *fp, Required: required, Err: errors.New("no observed composite resource")}
+		}
+
+		from = oxr.Resource.Object
+	case v1beta1.FromEnvironmentFieldPath:
+		m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(env)
+		if err != nil {
+			return &SourceError{Type: t, FieldPath: *fp, Required: required, Err: errors.Wrap(err, "cannot convert Environment to unstructured map")}
+		}
+
+		from = m
+	default:
+		return &SourceError{Type: t, FieldPath: *fp, Required: required, Err: errors.Errorf("unsupported source type %q", t)}
+	}
+
+	err := fieldpath.Pave(from).GetValueInto(*fp, into)
+	if err == nil {
+		return nil
+	}
+
+	if fieldpath.IsNotFound(err) && !required {
+		return nil
+	}
+
+	return &SourceError{Type: t, FieldPath: *fp, Required: required, Err: err}
+}
+
+// Warnings collects warning messages and the resources they apply to, so
+// that an error shared by many resources is only reported once.
+type Warnings map[string][]resource.Name
+
+// Add records an error for a resource. An error is recorded once per resource.
+func (w Warnings) Add(name resource.Name, err error) {
+	if slices.Contains(w[err.Error()], name) {
+		return
+	}
+
+	w[err.Error()] = append(w[err.Error()], name)
+}
+
+// AddSourceErrors records errors reading sources for a resource. It returns
+// the first error of a required source, which should fail the function.
+func (w Warnings) AddSourceErrors(name resource.Name, serrs []SourceError) error {
+	for _, serr := range serrs {
+		if serr.Required {
+			return serr
+		}
+
+		w.Add(name, serr)
+	}
+
+	return nil
+}
+
+// Report returns a warning result for every message, naming the resources
+// it applies to. It returns the number of warnings.
+func (w Warnings) Report(rsp *fnv1.RunFunctionResponse) int {
+	for _, msg := range slices.Sorted(maps.Keys(w)) {
+		names := make([]string, 0, len(w[msg]))
+		for _, name := range w[msg] {
+			names = append(names, string(name))
+		}
+
+		slices.Sort(names)
+		response.Warning(rsp, errors.Errorf("%s (resources: %s)", msg, strings.Join(names, ", ")))
+	}
+
+	return len(w)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+)
+
+func TestReadSource(t *testing.T) {
+	oxr := &resource.Composite{
+		Resource: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{
+				"tags":      map[string]any{"owner": "platform"},
+				"malformed": "not-a-map",
+			},
+		}}},
+	}
+
+	env := &unstructured.Unstructured{Object: map[string]any{
+		"tags": map[string]any{"env": "dev"},
+	}}
+
+	type args struct {
+		t        v1beta1.TagManagerType
+		fp       *string
+		required bool
+	}
+
+	type want struct {
+		tags v1beta1.Tags
+		serr *SourceError
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Composite": {
+			reason: "Tags are read from the Composite",
+			args:   args{t: v1beta1.FromCompositeFieldPath, fp: ptr.To("spec.tags")},
+			want:   want{tags: v1beta1.Tags{"owner": "platform"}},
+		},
+		"Environment": {
+			reason: "Tags are read from the Environment",
+			args:   args{t: v1beta1.FromEnvironmentFieldPath, fp: ptr.To("tags")},
+			want:   want{tags: v1beta1.Tags{"env": "dev"}},
+		},
+		"MissingOptional": {
+			reason: "A missing optional field is not an error",
+			args:   args{t: v1beta1.FromCompositeFieldPath, fp: ptr.To("spec.missing")},
+			want:   want{},
+		},
+		"MissingRequired": {
+			reason: "A missing required field is an error",
+			args:   args{t: v1beta1.FromCompositeFieldPath, fp: ptr.To("spec.missing"), required: true},
+			want: want{serr: &SourceError{
+				Type:      v1beta1.FromCompositeFieldPath,
+				FieldPath: "spec.missing",
+				Required:  true,
+			}},
+		},
+		"Malformed": {
+			reason: "A field that is not a map of tags is an error",
+			args:   args{t: v1beta1.FromCompositeFieldPath, fp: ptr.To("spec.malformed")},
+			want: want{serr: &SourceError{
+				Type:      v1beta1.FromCompositeFieldPath,
+				FieldPath: "spec.malformed",
+			}},
+		},
+		"NoFieldPath": {
+			reason: "A source without a field path is an error",
+			args:   args{t: v1beta1.FromCompositeFieldPath},
+			want:   want{serr: &SourceError{Type: v1beta1.FromCompositeFieldPath}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var tags v1beta1.Tags
+
+			serr := readSource(tc.args.t, tc.args.fp, tc.args.required, oxr, env, &tags)
+
+			if diff := cmp.Diff(tc.want.tags, tags); diff != "" {
+				t.Errorf("%s\nreadSource(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.serr, serr, cmpopts.IgnoreFields(SourceError{}, "Err")); diff != "" {
+				t.Errorf("%s\nreadSource(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	w := Warnings{}
+
+	shared := errors.New("shared")
+	w.Add("b", shared)
+	w.Add("a", shared)
+
+	err := w.AddSourceErrors("c", []SourceError{
+		{Type: v1beta1.FromCompositeFieldPath, FieldPath: "spec.optional", Err: errors.New("boom")},
+		{Type: v1beta1.FromCompositeFieldPath, FieldPath: "spec.required", Required: true, Err: errors.New("boom")},
+	})
+	if err == nil {
+		t.Errorf("AddSourceErrors(...): want error for required source, got nil")
+	}
+
+	rsp := &fnv1.RunFunctionResponse{}
+	if got := w.Report(rsp); got != 2 {
+		t.Errorf("Report(...): want 2 warnings, got %d", got)
+	}
+
+	want := []string{
+		`cannot resolve FromCompositeFieldPath source "spec.optional": boom (resources: c)`,
+		"shared (resources: a, b)",
+	}
+
+	got := make([]string, 0, len(rsp.GetResults()))
+	for _, r := range rsp.GetResults() {
+		got = append(got, r.GetMessage())
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Report(...): -want, +got:\n%s", diff)
+	}
+}
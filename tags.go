@@ -1,13 +1,15 @@
 package main
 
 import (
+	"fmt"
+
 	"dario.cat/mergo"
+	"github.com/crossplane-contrib/function-tag-manager/filters"
 	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
 	"github.com/crossplane/function-sdk-go/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
 
-	"github.com/crossplane/crossplane-runtime/v2/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
 )
 
 // IgnoreResourceAnnotation set this annotation to `True` or `true` to disable
@@ -27,9 +29,15 @@ type TagUpdater struct {
 	Retain v1beta1.Tags
 }
 
-// ResolveAddTags returns tags that will be Retained and Replaced.
-func (f *Function) ResolveAddTags(in []v1beta1.AddTag, oxr *resource.Composite, env *unstructured.Unstructured) TagUpdater {
+// ResolveAddTags returns tags that will be Retained and Replaced. Tags read
+// from the Composite or Environment are validated against the rules, and the
+// values the rules dropped or replaced are returned. The name and desired
+// composed resource are only used to render FromTemplate tags. Sources that
+// cannot be read are returned as SourceErrors.
+func (f *Function) ResolveAddTags(in []v1beta1.AddTag, rules []TagRule, oxr *resource.Composite, env *unstructured.Unstructured, name resource.Name, desired *resource.DesiredComposed) (TagUpdater, []SourceError, []string, error) {
 	tu := TagUpdater{}
+	serrs := make([]SourceError, 0)
+	changes := make([]string, 0)
 
 	for _, at := range in {
 		var tags v1beta1.Tags
@@ -37,23 +45,34 @@ func (f *Function) ResolveAddTags(in []v1beta1.AddTag, oxr *resource.Composite,
 		switch t := at.GetType(); t {
 		case v1beta1.FromValue:
 			_ = mergo.Map(&tags, at.Tags)
-		case v1beta1.FromCompositeFieldPath: // resolve fields
-			err := fieldpath.Pave(oxr.Resource.Object).GetValueInto(*at.FromFieldPath, &tags)
-			if err != nil {
-				f.log.Debug("Unable to read tags from Composite field: ", *at.FromFieldPath, err)
+		case v1beta1.FromCompositeFieldPath, v1beta1.FromEnvironmentFieldPath: // resolve fields
+			if serr := readSource(t, at.FromFieldPath, at.Required, oxr, env, &tags); serr != nil {
+				f.log.Debug("Unable to read tags", "resource", string(name), "error", serr.Error())
+				serrs = append(serrs, *serr)
+
+				continue
 			}
-		case v1beta1.FromEnvironmentFieldPath:
-			fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(env)
+
+			valid, ruleChanges, err := ApplyTagRules(tags, rules)
 			if err != nil {
-				f.log.Debug("Unable to convert Environment field to unstructured map: ", *at.FromFieldPath, err)
-				continue
+				return TagUpdater{}, serrs, changes, errors.Wrapf(err, "invalid tags in %s field %q", t, *at.FromFieldPath)
+			}
+
+			for _, c := range ruleChanges {
+				changes = append(changes, fmt.Sprintf("%s field %q: %s", t, *at.FromFieldPath, c))
 			}
 
-			err = fieldpath.Pave(fromMap).GetValueInto(*at.FromFieldPath, &tags)
+			tags = valid
+		case v1beta1.FromTemplate:
+			rendered, err := RenderTemplateTags(at.Tags, TemplateData(oxr, env, name, desired))
 			if err != nil {
-				f.log.Debug("Unable to read tags from Environment field: ", *at.FromFieldPath, err)
+				f.log.Debug("Unable to render tag templates", "resource", string(name), "error", err.Error())
+				serrs = append(serrs, SourceError{Type: t, Required: at.Required, Err: err})
+
 				continue
 			}
+
+			tags = rendered
 		}
 
 		if at.GetPolicy() == v1beta1.ExistingTagPolicyRetain {
@@ -63,14 +82,12 @@ func (f *Function) ResolveAddTags(in []v1beta1.AddTag, oxr *resource.Composite,
 		}
 	}
 
-	return tu
+	return tu, serrs, changes, nil
 }
 
-// MergeTags merges tags to a Desired Composed Resource.
-func MergeTags(desired *resource.DesiredComposed, tu TagUpdater) error {
-	var desiredTags v1beta1.Tags
-
-	_ = fieldpath.Pave(desired.Resource.Object).GetValueInto("spec.forProvider.tags", &desiredTags)
+// MergeTags merges tags to a tag path of a Desired Composed Resource.
+func MergeTags(desired *resource.DesiredComposed, tu TagUpdater, p filters.TagPath) error {
+	desiredTags, _ := GetTags(desired.Resource.Object, p.Desired, p.GetShape())
 
 	err := mergo.Map(&desiredTags, tu.Retain)
 	if err != nil {
@@ -82,25 +99,23 @@ func MergeTags(desired *resource.DesiredComposed, tu TagUpdater) error {
 		return err
 	}
 
-	err = desired.Resource.SetValue("spec.forProvider.tags", desiredTags)
-
-	return err
+	return SetTags(desired, p, desiredTags)
 }
 
-// ResolveIgnoreTags returns tags that are populated from observed resources.
-func (f *Function) ResolveIgnoreTags(in []v1beta1.IgnoreTag, oxr *resource.Composite, observed *resource.ObservedComposed, env *unstructured.Unstructured) *TagUpdater {
+// ResolveIgnoreTags returns tags that are populated from the tag path of observed
+// resources. Sources that cannot be read are returned as SourceErrors.
+func (f *Function) ResolveIgnoreTags(in []v1beta1.IgnoreTag, oxr *resource.Composite, observed *resource.ObservedComposed, env *unstructured.Unstructured, p filters.TagPath) (*TagUpdater, []SourceError) {
 	tu := &TagUpdater{}
+	serrs := make([]SourceError, 0)
 
 	if observed == nil {
-		return nil
+		return nil, serrs
 	}
 
-	var observedTags v1beta1.Tags
-
-	err := fieldpath.Pave(observed.Resource.Object).GetValueInto("status.atProvider.tags", &observedTags)
+	observedTags, err := GetTags(observed.Resource.Object, p.Observed, p.GetShape())
 	if err != nil {
 		f.log.Debug("unable to fetch tags from observed resource", observed.Resource.GetName(), observed.Resource.GroupVersionKind().String())
-		return nil
+		return nil, serrs
 	}
 
 	for _, at := range in {
@@ -111,21 +126,11 @@ func (f *Function) ResolveIgnoreTags(in []v1beta1.IgnoreTag, oxr *resource.Compo
 		switch t := at.GetType(); t {
 		case v1beta1.FromValue:
 			keys = at.Keys
-		case v1beta1.FromCompositeFieldPath: // resolve fields
-			err := fieldpath.Pave(oxr.Resource.Object).GetValueInto(*at.FromFieldPath, &keys)
-			if err != nil {
-				f.log.Debug("Unable to read tags from Composite field: ", *at.FromFieldPath, err)
-			}
-		case v1beta1.FromEnvironmentFieldPath:
-			fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(env)
-			if err != nil {
-				f.log.Debug("Unable to convert Environment field to unstructured map: ", *at.FromFieldPath, err)
-				continue
-			}
+		case v1beta1.FromCompositeFieldPath, v1beta1.FromEnvironmentFieldPath: // resolve fields
+			if serr := readSource(t, at.FromFieldPath, at.Required, oxr, env, &keys); serr != nil {
+				f.log.Debug("Unable to read tag keys to ignore", "error", serr.Error())
+				serrs = append(serrs, *serr)
 
-			err = fieldpath.Pave(fromMap).GetValueInto(*at.FromFieldPath, &keys)
-			if err != nil {
-				f.log.Debug("Unable to read tags from Environment field: ", *at.FromFieldPath, err)
 				continue
 			}
 		}
@@ -149,52 +154,44 @@ func (f *Function) ResolveIgnoreTags(in []v1beta1.IgnoreTag, oxr *resource.Compo
 		}
 	}
 
-	return tu
+	return tu, serrs
 }
 
 // ResolveRemoveTags resolves the list of tag keys that will be removed.
-func (f *Function) ResolveRemoveTags(in []v1beta1.RemoveTag, oxr *resource.Composite, env *unstructured.Unstructured) []string {
+// Sources that cannot be read are returned as SourceErrors.
+func (f *Function) ResolveRemoveTags(in []v1beta1.RemoveTag, oxr *resource.Composite, env *unstructured.Unstructured) ([]string, []SourceError) {
 	tagKeys := make([]string, 0)
-	tags := make([]string, 0)
+	serrs := make([]SourceError, 0)
 
 	for _, at := range in {
 		switch t := at.GetType(); t {
 		case v1beta1.FromValue:
 			tagKeys = append(tagKeys, at.Keys...)
-		case v1beta1.FromCompositeFieldPath: // resolve fields
-			err := fieldpath.Pave(oxr.Resource.Object).GetValueInto(*at.FromFieldPath, &tags)
-			if err != nil {
-				f.log.Debug("Unable to read tags from Composite field: ", *at.FromFieldPath, err)
-			}
+		case v1beta1.FromCompositeFieldPath, v1beta1.FromEnvironmentFieldPath: // resolve fields
+			var keys []string
 
-		case v1beta1.FromEnvironmentFieldPath:
-			fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(env)
-			if err != nil {
-				f.log.Debug("Unable convert Environment field: ", *at.FromFieldPath, err)
-			}
+			if serr := readSource(t, at.FromFieldPath, at.Required, oxr, env, &keys); serr != nil {
+				f.log.Debug("Unable to read tag keys to remove", "error", serr.Error())
+				serrs = append(serrs, *serr)
 
-			err = fieldpath.Pave(fromMap).GetValueInto(*at.FromFieldPath, &tags)
-			if err != nil {
-				f.log.Debug("Unable to read tags from Environment field: ", *at.FromFieldPath, err)
+				continue
 			}
+
+			tagKeys = append(tagKeys, keys...)
 		}
 	}
 
-	tagKeys = append(tagKeys, tags...)
-
-	return tagKeys
+	return tagKeys, serrs
 }
 
-// RemoveTags removes tags from a desired composed resource based
-// on matching keys.
-func RemoveTags(desired *resource.DesiredComposed, keys []string) error {
+// RemoveTags removes tags from a tag path of a desired composed resource
+// based on matching keys.
+func RemoveTags(desired *resource.DesiredComposed, keys []string, p filters.TagPath) error {
 	if len(keys) == 0 {
 		return nil
 	}
 
-	var desiredTags v1beta1.Tags
-
-	_ = fieldpath.Pave(desired.Resource.Object).GetValueInto("spec.forProvider.tags", &desiredTags)
+	desiredTags, _ := GetTags(desired.Resource.Object, p.Desired, p.GetShape())
 
 	numTags := len(desiredTags)
 	for _, key := range keys {
@@ -202,7 +199,7 @@ func RemoveTags(desired *resource.DesiredComposed, keys []string) error {
 	}
 
 	if numTags > 0 {
-		return desired.Resource.SetValue("spec.forProvider.tags", desiredTags)
+		return SetTags(desired, p, desiredTags)
 	}
 
 	return nil
@@ -3,6 +3,7 @@ package main
 import (
 	"testing"
 
+	"github.com/crossplane-contrib/function-tag-manager/filters"
 	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
 	"github.com/crossplane/function-sdk-go/resource"
 	"github.com/crossplane/function-sdk-go/resource/composed"
@@ -10,6 +11,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
 
 	"github.com/crossplane/crossplane-runtime/v2/pkg/logging"
 )
@@ -22,13 +24,19 @@ func TestResolveAddTags(t *testing.T) {
 	envFieldPathReplace := "tagsReplace"
 
 	type args struct {
-		in  []v1beta1.AddTag
-		oxr *resource.Composite
-		env *unstructured.Unstructured
+		in      []v1beta1.AddTag
+		rules   []v1beta1.TagRule
+		oxr     *resource.Composite
+		env     *unstructured.Unstructured
+		name    resource.Name
+		desired *resource.DesiredComposed
 	}
 
 	type want struct {
-		tu TagUpdater
+		tu      TagUpdater
+		serrs   []SourceError
+		changes []string
+		err     error
 	}
 
 	cases := map[string]struct {
@@ -41,7 +49,7 @@ func TestResolveAddTags(t *testing.T) {
 			args: args{
 				in: []v1beta1.AddTag{},
 			},
-			want: want{TagUpdater{}},
+			want: want{tu: TagUpdater{}},
 		},
 		"SimpleFromValue": {
 			reason: "TagUpdater should be populated correctly from simple values",
@@ -51,7 +59,7 @@ func TestResolveAddTags(t *testing.T) {
 					{Type: v1beta1.FromValue, Tags: v1beta1.Tags{"replace": "me"}, Policy: "Replace"},
 				},
 			},
-			want: want{TagUpdater{
+			want: want{tu: TagUpdater{
 				Replace: v1beta1.Tags{"replace": "me"},
 				Retain:  v1beta1.Tags{"retain": "me", "retain2": "me2"},
 			}},
@@ -64,7 +72,7 @@ func TestResolveAddTags(t *testing.T) {
 					{Type: v1beta1.FromValue, Tags: v1beta1.Tags{"replace": "me"}},
 				},
 			},
-			want: want{TagUpdater{
+			want: want{tu: TagUpdater{
 				Replace: v1beta1.Tags{"replace": "me"},
 				Retain:  v1beta1.Tags{"retain": "me", "retain2": "me2"},
 			}},
@@ -77,7 +85,7 @@ func TestResolveAddTags(t *testing.T) {
 					{Type: v1beta1.FromValue, Tags: v1beta1.Tags{"replace": "me"}},
 				},
 			},
-			want: want{TagUpdater{
+			want: want{tu: TagUpdater{
 				Replace: v1beta1.Tags{"replace": "me"},
 				Retain:  v1beta1.Tags{"retain": "", "retain2": ""},
 			}},
@@ -124,7 +132,7 @@ func TestResolveAddTags(t *testing.T) {
 				},
 			},
 			want: want{
-				TagUpdater{
+				tu: TagUpdater{
 					Replace: v1beta1.Tags{"fromField": "fromXR", "fromField2": "fromXR2", "replace": "me"},
 					Retain:  v1beta1.Tags{"optionalKey": "fromXR", "optionalKey2": "fromXR2"},
 				},
@@ -156,22 +164,193 @@ func TestResolveAddTags(t *testing.T) {
 			},
 
 			want: want{
-				TagUpdater{
+				tu: TagUpdater{
 					Replace: v1beta1.Tags{"tag2": "replace"},
 					Retain:  v1beta1.Tags{"tag1": "retain"},
 				},
 			},
 		},
+		"ValuesFromTemplate": {
+			reason: "Test rendering tags from templates over the XR, Environment and desired resource",
+			args: args{
+				in: []v1beta1.AddTag{
+					{
+						Type: v1beta1.FromTemplate,
+						Tags: v1beta1.Tags{
+							"owner":                "{{ .xr.metadata.labels.team }}-{{ .env.region }}",
+							"{{ .env.prefix }}/id": "{{ .name }}-{{ .resource.kind }}",
+						},
+					},
+					{
+						Type:   v1beta1.FromTemplate,
+						Tags:   v1beta1.Tags{"missing": "{{ .xr.spec.missing }}"},
+						Policy: v1beta1.ExistingTagPolicyRetain,
+					},
+				},
+				oxr: &resource.Composite{
+					Resource: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+						"apiVersion": "example.crossplane.io/v1",
+						"kind":       "XR",
+						"metadata": map[string]any{
+							"name": "test-resource",
+							"labels": map[string]any{
+								"team": "platform",
+							},
+						},
+						"spec": map[string]any{},
+					}}},
+				},
+				env: &unstructured.Unstructured{Object: map[string]any{
+					"region": "us-west-2",
+					"prefix": "example.com",
+				}},
+				name: "vpc",
+				desired: &resource.DesiredComposed{
+					Resource: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+						"apiVersion": "ec2.aws.upbound.io/v1beta1",
+						"kind":       "VPC",
+					}}},
+				},
+			},
+			want: want{
+				tu: TagUpdater{
+					Replace: v1beta1.Tags{"owner": "platform-us-west-2", "example.com/id": "vpc-VPC"},
+				},
+				serrs: []SourceError{{Type: v1beta1.FromTemplate}},
+			},
+		},
+		"SourceErrors": {
+			reason: "Missing optional fields are skipped, while malformed and missing required fields return SourceErrors",
+			args: args{
+				in: []v1beta1.AddTag{
+					{
+						FromFieldPath: ptr.To("spec.missing"),
+						Type:          v1beta1.FromCompositeFieldPath,
+					},
+					{
+						FromFieldPath: ptr.To("spec.malformed"),
+						Type:          v1beta1.FromCompositeFieldPath,
+					},
+					{
+						FromFieldPath: ptr.To("spec.requiredTags"),
+						Type:          v1beta1.FromCompositeFieldPath,
+						Required:      true,
+					},
+					{
+						Type: v1beta1.FromValue,
+						Tags: v1beta1.Tags{"replace": "me"},
+					},
+				},
+				oxr: &resource.Composite{
+					Resource: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+						"spec": map[string]any{
+							"malformed": "not-a-map",
+						},
+					}}},
+				},
+			},
+			want: want{
+				tu: TagUpdater{
+					Replace: v1beta1.Tags{"replace": "me"},
+				},
+				serrs: []SourceError{
+					{Type: v1beta1.FromCompositeFieldPath, FieldPath: "spec.malformed"},
+					{Type: v1beta1.FromCompositeFieldPath, FieldPath: "spec.requiredTags", Required: true},
+				},
+			},
+		},
+		"RulesFromComposite": {
+			reason: "Tags from a Composite field path are validated against tag rules, FromValue tags are not",
+			args: args{
+				in: []v1beta1.AddTag{
+					{
+						FromFieldPath: &fieldPath,
+						Type:          v1beta1.FromCompositeFieldPath,
+					},
+					{
+						Type: v1beta1.FromValue,
+						Tags: v1beta1.Tags{"cost-center": "shared"},
+					},
+				},
+				rules: []v1beta1.TagRule{
+					{Key: "environment", AllowedValues: []string{"dev", "stage", "prod"}, Action: v1beta1.TagRuleActionUseDefault, Default: ptr.To("dev")},
+					{Key: "cost-center", Pattern: ptr.To("^CC-[0-9]{4}$")},
+				},
+				oxr: &resource.Composite{
+					Resource: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+						"spec": map[string]any{
+							"additionalTags": map[string]any{
+								"environment": "test",
+								"cost-center": "1234",
+								"owner":       "platform",
+							},
+						},
+					}}},
+				},
+			},
+			want: want{
+				tu: TagUpdater{
+					Replace: v1beta1.Tags{"environment": "dev", "owner": "platform", "cost-center": "shared"},
+				},
+				changes: []string{
+					`FromCompositeFieldPath field "spec.additionalTags": replaced invalid value "test" of tag "environment" with "dev": value must be one of [dev stage prod]`,
+					`FromCompositeFieldPath field "spec.additionalTags": dropped invalid value "1234" of tag "cost-center": value must match "^CC-[0-9]{4}$"`,
+				},
+			},
+		},
+		"RulesFail": {
+			reason: "A tag rule with the Fail action returns an error",
+			args: args{
+				in: []v1beta1.AddTag{
+					{
+						FromFieldPath: &fieldPath,
+						Type:          v1beta1.FromCompositeFieldPath,
+					},
+				},
+				rules: []v1beta1.TagRule{
+					{Key: "cost-center", Pattern: ptr.To("^CC-[0-9]{4}$"), Action: v1beta1.TagRuleActionFail},
+				},
+				oxr: &resource.Composite{
+					Resource: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+						"spec": map[string]any{
+							"additionalTags": map[string]any{
+								"cost-center": "1234",
+							},
+						},
+					}}},
+				},
+			},
+			want: want{
+				err: cmpopts.AnyError,
+			},
+		},
 	}
 	f := &Function{log: logging.NewNopLogger()}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got := f.ResolveAddTags(tc.args.in, tc.args.oxr, tc.args.env)
+			rules, err := CompileTagRules(tc.args.rules)
+			if err != nil {
+				t.Fatalf("%s\nCompileTagRules(...): %v", tc.reason, err)
+			}
+
+			got, serrs, changes, err := f.ResolveAddTags(tc.args.in, rules, tc.args.oxr, tc.args.env, tc.args.name, tc.args.desired)
 
 			if diff := cmp.Diff(tc.want.tu, got); diff != "" {
 				t.Errorf("%s\nfResolveAddTags(): -want err, +got err:\n%s", tc.reason, diff)
 			}
+
+			if diff := cmp.Diff(tc.want.serrs, serrs, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(SourceError{}, "Err")); diff != "" {
+				t.Errorf("%s\nfResolveAddTags(): -want source errors, +got source errors:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.changes, changes, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%s\nfResolveAddTags(): -want changes, +got changes:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("%s\nfResolveAddTags(): -want err, +got err:\n%s", tc.reason, diff)
+			}
 		})
 	}
 }
@@ -246,7 +425,7 @@ func TestAddTags(t *testing.T) {
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			err := MergeTags(tc.args.desired, tc.args.tu)
+			err := MergeTags(tc.args.desired, tc.args.tu, filters.DefaultTagPath)
 
 			if diff := cmp.Diff(tc.want.desired, tc.args.desired); diff != "" {
 				t.Errorf("%s\nfAddTags(): -want err, +got err:\n%s", tc.reason, diff)
@@ -274,7 +453,8 @@ func TestResolveIgnoreTags(t *testing.T) {
 	}
 
 	type want struct {
-		tu *TagUpdater
+		tu    *TagUpdater
+		serrs []SourceError
 	}
 
 	cases := map[string]struct {
@@ -285,7 +465,7 @@ func TestResolveIgnoreTags(t *testing.T) {
 		"ReturnNilOnMissingStatus": {
 			reason: "With empty Observed Status return Nil",
 			args:   args{},
-			want:   want{nil},
+			want:   want{},
 		},
 
 		"EmptyInput": {
@@ -317,7 +497,7 @@ func TestResolveIgnoreTags(t *testing.T) {
 					}},
 				},
 			},
-			want: want{&TagUpdater{}},
+			want: want{tu: &TagUpdater{}},
 		},
 		"CorrectlyReadInput": {
 			reason: "Read IgnoreTag fields and correctly populate TagUpdater",
@@ -390,7 +570,7 @@ func TestResolveIgnoreTags(t *testing.T) {
 				},
 			},
 			want: want{
-				&TagUpdater{
+				tu: &TagUpdater{
 					Replace: v1beta1.Tags{
 						"XRKey":         "definedInXR",
 						"replaceField1": "fromObserved",
@@ -455,7 +635,7 @@ func TestResolveIgnoreTags(t *testing.T) {
 				}},
 			},
 			want: want{
-				&TagUpdater{
+				tu: &TagUpdater{
 					Replace: v1beta1.Tags{
 						"replaceEnvKey": "fromEnvReplace",
 					},
@@ -472,11 +652,15 @@ func TestResolveIgnoreTags(t *testing.T) {
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			tu := f.ResolveIgnoreTags(tc.args.in, tc.args.oxr, tc.args.observed, tc.args.env)
+			tu, serrs := f.ResolveIgnoreTags(tc.args.in, tc.args.oxr, tc.args.observed, tc.args.env, filters.DefaultTagPath)
 
 			if diff := cmp.Diff(tc.want.tu, tu); diff != "" {
 				t.Errorf("%s\nfResolveAddTags(): -want err, +got err:\n%s", tc.reason, diff)
 			}
+
+			if diff := cmp.Diff(tc.want.serrs, serrs, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(SourceError{}, "Err")); diff != "" {
+				t.Errorf("%s\nfResolveIgnoreTags(): -want source errors, +got source errors:\n%s", tc.reason, diff)
+			}
 		})
 	}
 }
@@ -493,7 +677,8 @@ func TestResolveRemoveTags(t *testing.T) {
 	}
 
 	type want struct {
-		keys []string
+		keys  []string
+		serrs []SourceError
 	}
 
 	cases := map[string]struct {
@@ -599,11 +784,15 @@ func TestResolveRemoveTags(t *testing.T) {
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got := f.ResolveRemoveTags(tc.args.in, tc.args.oxr, tc.args.env)
+			got, serrs := f.ResolveRemoveTags(tc.args.in, tc.args.oxr, tc.args.env)
 
 			if diff := cmp.Diff(tc.want.keys, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
 				t.Errorf("%s\nfResolveRemoveTags(): -want err, +got err:\n%s", tc.reason, diff)
 			}
+
+			if diff := cmp.Diff(tc.want.serrs, serrs, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(SourceError{}, "Err")); diff != "" {
+				t.Errorf("%s\nfResolveRemoveTags(): -want source errors, +got source errors:\n%s", tc.reason, diff)
+			}
 		})
 	}
 }
@@ -787,7 +976,7 @@ func TestRemoveTags(t *testing.T) {
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			err := RemoveTags(tc.args.desired, tc.args.keys)
+			err := RemoveTags(tc.args.desired, tc.args.keys, filters.DefaultTagPath)
 
 			if diff := cmp.Diff(tc.want.desired, tc.args.desired); diff != "" {
 				t.Errorf("%s\nfAddTags(): -want err, +got err:\n%s", tc.reason, diff)
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/errors"
+)
+
+// TemplateData returns the data FromTemplate tags are rendered against.
+func TemplateData(oxr *resource.Composite, env *unstructured.Unstructured, name resource.Name, desired *resource.DesiredComposed) map[string]any {
+	data := map[string]any{
+		"name": string(name),
+	}
+
+	if oxr != nil && oxr.Resource != nil {
+		data["xr"] = oxr.Resource.Object
+	}
+
+	if env != nil {
+		data["env"] = env.Object
+	}
+
+	if desired != nil && desired.Resource != nil {
+		data["resource"] = desired.Resource.Object
+	}
+
+	return data
+}
+
+// RenderTemplateTags renders the keys and values of tags as Go templates.
+// Referencing a field that does not exist is an error.
+func RenderTemplateTags(tags v1beta1.Tags, data map[string]any) (v1beta1.Tags, error) {
+	rendered := make(v1beta1.Tags, len(tags))
+
+	for k, v := range tags {
+		key, err := renderTemplate(k, data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot render template for tag key %q", k)
+		}
+
+		val, err := renderTemplate(v, data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot render template for tag %q", k)
+		}
+
+		rendered[key] = val
+	}
+
+	return rendered, nil
+}
+
+func renderTemplate(text string, data map[string]any) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("tag").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	err = tmpl.Execute(&b, data)
+	if err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
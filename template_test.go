@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/function-tag-manager/input/v1beta1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderTemplateTags(t *testing.T) {
+	data := map[string]any{
+		"name": "vpc",
+		"xr": map[string]any{
+			"metadata": map[string]any{
+				"name": "network",
+			},
+		},
+	}
+
+	type want struct {
+		tags v1beta1.Tags
+		err  bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		tags   v1beta1.Tags
+		want   want
+	}{
+		"PlainValues": {
+			reason: "Values without template actions are returned unchanged",
+			tags:   v1beta1.Tags{"key": "value", "empty": ""},
+			want:   want{tags: v1beta1.Tags{"key": "value", "empty": ""}},
+		},
+		"RenderKeysAndValues": {
+			reason: "Both keys and values are rendered",
+			tags:   v1beta1.Tags{"{{ .xr.metadata.name }}-name": "{{ .xr.metadata.name }}-{{ .name }}"},
+			want:   want{tags: v1beta1.Tags{"network-name": "network-vpc"}},
+		},
+		"MissingKey": {
+			reason: "Referencing a missing field returns an error",
+			tags:   v1beta1.Tags{"key": "{{ .xr.metadata.labels.team }}"},
+			want:   want{err: true},
+		},
+		"InvalidTemplate": {
+			reason: "An invalid template returns an error",
+			tags:   v1beta1.Tags{"key": "{{ .name "},
+			want:   want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := RenderTemplateTags(tc.tags, data)
+
+			if (err != nil) != tc.want.err {
+				t.Fatalf("%s\nRenderTemplateTags(...): want error %t, got %v", tc.reason, tc.want.err, err)
+			}
+
+			if diff := cmp.Diff(tc.want.tags, got); diff != "" {
+				t.Errorf("%s\nRenderTemplateTags(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}